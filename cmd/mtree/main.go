@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 
 	"github.com/curtisnewbie/grapher/graph"
@@ -15,72 +16,371 @@ import (
 )
 
 var (
-	FlagPom    = flag.String("pom", "", "maven pom file")
-	FlagFile   = flag.String("file", "", "mvn dependency:tree output file")
-	FlagFilter = flag.String("filter", "", "filter tree branches by label name for tree-shaking")
-	FlagFormat = flag.String("format", "png", "file format, e.g., svg, png, etc.")
-	FlagDpi    = flag.String("dpi", "", "dpi")
+	FlagPom       = flag.String("pom", "", "maven pom file")
+	FlagOffline   = flag.Bool("offline", false, "resolve dependencies using pom file and local repository without running mvn")
+	FlagRepo      = flag.String("repo", defaultRepo(), "local maven repository used in offline mode and by weight, dupclasses and upgrade commands")
+	FlagFile      = flag.String("file", "", "mvn dependency:tree output file, text, dot, graphml, tgf and json output types are detected automatically")
+	FlagFilter    = flag.String("filter", "", "filter tree branches by filter expression for tree-shaking, e.g., 'group:com.fasterxml* and not scope:test'")
+	FlagHighlight = flag.String("highlight", "", "highlight nodes by filter expression and the paths leading to them, without pruning the tree")
+	FlagCluster   = flag.Bool("cluster", false, "draw each reactor module as a cluster")
+	FlagModule    = flag.String("module", "", "only draw dependency tree of the reactor module with the given artifactId")
+	FlagFormat    = flag.String("format", "png", "file format, e.g., svg, png, etc.")
+	FlagDpi       = flag.String("dpi", "", "dpi")
+	FlagPolicy    = flag.String("policy", "mtree-policy.json", "policy file in JSON used by check command")
+	FlagReport    = flag.String("report", ReportText, "report format of commands, e.g., text, json, graph, sarif, junit")
+	FlagOutput    = flag.String("output", "", "write report of commands to the file instead of stdout")
+	FlagArtifact  = flag.String("artifact", "", "groupId[:artifactId] pattern of unwanted artifacts used by exclude command, e.g., 'commons-logging:*', or groupId:artifactId:version used by upgrade command")
+)
+
+const (
+	ReportText  = "text"
+	ReportJSON  = "json"
+	ReportGraph = "graph"
+	ReportSARIF = "sarif"
+	ReportJUnit = "junit"
 )
 
 func main() {
-	flag.Parse()
+	flag.Usage = usage
+	cmd, args := "", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+	}
+	_ = flag.CommandLine.Parse(args)
 
-	var dat []byte = nil
+	// upgrade resolves the pom by itself, before and after the upgrade
+	if cmd == "upgrade" {
+		simulateUpgrade()
+		return
+	}
 
-	// mvn dependency:tree output file
-	if *FlagFile != "" {
-		ctn, err := os.ReadFile(*FlagFile)
+	// commands other than drawing the tree rely on dependencies omitted for conflict or duplicate
+	g, err := parseInput(cmd != "")
+	if err != nil {
+		panic(err)
+	}
+	if g == nil {
+		fmt.Println("Has nothing to process")
+		usage()
+		return
+	}
+
+	if *FlagModule != "" {
+		g, err = moduleSubgraph(g, *FlagModule)
 		if err != nil {
 			panic(err)
 		}
-		dat = ctn
 	}
 
-	// stdin
-	if len(dat) < 1 {
-		fi, err := os.Stdin.Stat()
+	switch cmd {
+	case "":
+		drawTree(g)
+	case "conflicts":
+		reportConflicts(g)
+	case "check":
+		if !checkPolicy(g) {
+			os.Exit(1)
+		}
+	case "exclude":
+		suggestExclusions(g)
+	case "weight":
+		rankWeights(g)
+	case "dupclasses":
+		reportDuplicateClasses(g)
+	default:
+		fmt.Printf("Unknown command '%v'\n", cmd)
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), `Usage of mtree: mtree [command] [flags]
+
+Commands:
+  (none)     draw the dependency tree
+  conflicts  report artifacts that appear with multiple versions
+  check      check dependencies against policy, exit with non-zero code if any rule is violated
+  exclude    suggest exclusions for the artifacts matching -artifact
+  weight     rank direct dependencies by the sizes of jars they bring in, jars are found in -repo
+  dupclasses report classes present in multiple jars on the runtime classpath, jars are found in -repo
+  upgrade    show changes of the dependencies if the artifact is upgraded to the version in -artifact, requires -pom
+
+Flags:
+`)
+	flag.PrintDefaults()
+}
+
+// Draw the dependency tree.
+func drawTree(g *mvn.MvnGraph) {
+	if *FlagCluster {
+		g.ClusterByModule()
+	}
+
+	if *FlagFilter != "" {
+		f, err := graph.ParseFilter(*FlagFilter)
 		if err != nil {
 			panic(err)
 		}
-		if (fi.Mode() & os.ModeCharDevice) == 0 {
-			fmt.Println("Reading from stdin")
-			pipe, err := io.ReadAll(os.Stdin)
-			if err != nil && !errors.Is(err, io.EOF) {
-				panic(err)
+		g.TreeShake(f.Predicate(g.DGraph))
+	}
+
+	if *FlagHighlight != "" {
+		f, err := graph.ParseFilter(*FlagHighlight)
+		if err != nil {
+			panic(err)
+		}
+		g.Highlight(f.Predicate(g.DGraph))
+	}
+	draw(g)
+}
+
+// Report version conflicts.
+func reportConflicts(g *mvn.MvnGraph) {
+	if !g.Verbose() {
+		fmt.Fprintln(os.Stderr, "Dependencies omitted for conflict are not found, conflicts are only available in the output of mvn dependency:tree -Dverbose")
+	}
+	conflicts := g.Conflicts()
+	switch *FlagReport {
+	case ReportJSON:
+		b, err := mvn.FormatConflictsJSON(conflicts)
+		if err != nil {
+			panic(err)
+		}
+		writeReport(b)
+	case ReportSARIF, ReportJUnit:
+		writeFindings(g, mvn.ConflictViolations(conflicts), []string{mvn.RuleVersionConflict})
+	case ReportGraph:
+		g.HighlightConflicts(conflicts)
+		draw(g)
+	default:
+		if len(conflicts) < 1 {
+			fmt.Println("No conflict found")
+			return
+		}
+		fmt.Print(mvn.FormatConflicts(conflicts))
+	}
+}
+
+// Check dependencies against policy, return false if any rule is violated.
+func checkPolicy(g *mvn.MvnGraph) bool {
+	p, err := mvn.LoadPolicy(*FlagPolicy)
+	if err != nil {
+		panic(err)
+	}
+	violations, err := p.Check(g)
+	if err != nil {
+		panic(err)
+	}
+	switch *FlagReport {
+	case ReportJSON:
+		b, err := mvn.FormatViolationsJSON(violations)
+		if err != nil {
+			panic(err)
+		}
+		writeReport(b)
+	case ReportSARIF, ReportJUnit:
+		writeFindings(g, violations, p.Rules())
+	case ReportGraph:
+		ids := map[int]struct{}{}
+		for _, v := range violations {
+			for _, id := range v.NodeIds {
+				ids[id] = struct{}{}
 			}
-			dat = pipe
 		}
+		g.Highlight(func(n graph.Node) bool {
+			_, ok := ids[n.Id]
+			return ok
+		})
+		draw(g)
+	default:
+		if len(violations) < 1 {
+			fmt.Println("No violation found")
+		} else {
+			fmt.Print(mvn.FormatViolations(violations))
+			fmt.Printf("\nFound %d violations\n", len(violations))
+		}
+	}
+	return len(violations) < 1
+}
+
+// Suggest exclusions for unwanted artifacts, graph report draws the graph with the exclusions applied.
+func suggestExclusions(g *mvn.MvnGraph) {
+	if *FlagArtifact == "" {
+		fmt.Println("Missing -artifact for exclude command")
+		usage()
+		os.Exit(2)
+	}
+	r, err := g.SuggestExclusions(*FlagArtifact)
+	if err != nil {
+		panic(err)
+	}
+	switch *FlagReport {
+	case ReportJSON:
+		b, err := mvn.FormatExclusionsJSON(r)
+		if err != nil {
+			panic(err)
+		}
+		writeReport(b)
+	case ReportGraph:
+		preview, err := g.ApplyExclusions(r)
+		if err != nil {
+			panic(err)
+		}
+		draw(preview)
+	default:
+		if len(r.Suggestions) < 1 && len(r.Declared) < 1 {
+			fmt.Printf("No artifact matches '%v'\n", r.Pattern)
+			return
+		}
+		fmt.Print(mvn.FormatExclusions(r))
 	}
+}
 
-	// pom
-	if len(dat) < 1 && *FlagPom != "" {
-		cmd := exec.Command("mvn", "dependency:tree", "-f", *FlagPom)
-		cmdout, err := cmd.CombinedOutput()
+// Rank direct dependencies by the sizes of jars found in the local repository, graph report draws the graph with nodes
+// filled by weights.
+func rankWeights(g *mvn.MvnGraph) {
+	if missing := g.AnnotateSizes(*FlagRepo); len(missing) > 0 {
+		fmt.Fprintf(os.Stderr, "%d artifacts not found in %v, they are weighted as 0\n", len(missing), *FlagRepo)
+	}
+	weights, err := g.Weights()
+	if err != nil {
+		panic(err)
+	}
+	switch *FlagReport {
+	case ReportJSON:
+		b, err := mvn.FormatWeightsJSON(weights)
 		if err != nil {
 			panic(err)
 		}
-		dat = cmdout
+		writeReport(b)
+	case ReportGraph:
+		g.HighlightWeights(weights)
+		draw(g)
+	default:
+		fmt.Print(mvn.FormatWeights(weights))
 	}
+}
 
-	if len(dat) < 1 {
-		fmt.Println("Has nothing to process")
-		flag.PrintDefaults()
-		return
+// Report classes present in multiple jars found in the local repository, graph report draws the graph with the
+// artifacts containing duplicate classes highlighted.
+func reportDuplicateClasses(g *mvn.MvnGraph) {
+	dups, missing, err := g.DuplicateClasses(*FlagRepo)
+	if err != nil {
+		panic(err)
 	}
+	if len(missing) > 0 {
+		fmt.Fprintf(os.Stderr, "%d artifacts not found in %v, they are not scanned\n", len(missing), *FlagRepo)
+	}
+	switch *FlagReport {
+	case ReportJSON:
+		b, err := mvn.FormatDuplicateClassesJSON(dups)
+		if err != nil {
+			panic(err)
+		}
+		writeReport(b)
+	case ReportGraph:
+		g.HighlightDuplicateClasses(dups)
+		draw(g)
+	default:
+		if len(dups) < 1 {
+			fmt.Println("No duplicate class found")
+			return
+		}
+		fmt.Print(mvn.FormatDuplicateClasses(dups))
+	}
+}
 
-	g, err := mvn.ParseMvnTree(fmt.Sprintf("dependency graph %s", *FlagFile), string(dat))
+// Simulate upgrade of the artifact using poms in the local repository, graph report draws the graph after the
+// upgrade with the changed nodes highlighted. The graph before the upgrade is read from -file or stdin if provided,
+// otherwise it's resolved from -pom as well.
+func simulateUpgrade() {
+	if *FlagPom == "" || *FlagArtifact == "" {
+		fmt.Println("Missing -pom or -artifact for upgrade command")
+		usage()
+		os.Exit(2)
+	}
+	u, err := mvn.ParseUpgrade(*FlagArtifact)
 	if err != nil {
 		panic(err)
 	}
+	title, param := fmt.Sprintf("dependency graph %s", *FlagPom), mvn.PomParam{Repo: *FlagRepo}
+	var impact *mvn.UpgradeImpact
+	if *FlagFile != "" || stdinPiped() {
+		// the output of mvn dependency:tree -Dverbose is used as the graph before the upgrade
+		before, err := parseInput(true)
+		if err != nil {
+			panic(err)
+		}
+		impact, err = mvn.SimulateUpgradeFrom(before, title, *FlagPom, u, param)
+		if err != nil {
+			panic(err)
+		}
+	} else {
+		impact, err = mvn.SimulateUpgrade(title, *FlagPom, u, param)
+		if err != nil {
+			panic(err)
+		}
+	}
+	switch *FlagReport {
+	case ReportJSON:
+		b, err := mvn.FormatUpgradeImpactJSON(impact)
+		if err != nil {
+			panic(err)
+		}
+		writeReport(b)
+	case ReportGraph:
+		g := impact.After
+		g.HighlightChanges(&impact.GraphDiff)
+		if *FlagModule != "" {
+			if g, err = moduleSubgraph(g, *FlagModule); err != nil {
+				panic(err)
+			}
+		}
+		draw(g)
+	default:
+		fmt.Print(mvn.FormatUpgradeImpact(impact))
+	}
+}
 
-	if *FlagFilter != "" {
-		g.TreeShake(func(n graph.Node) bool { return strings.Contains(n.Label, *FlagFilter) })
+// Write findings in SARIF or JUnit XML, findings point to the pom file of the module.
+func writeFindings(g *mvn.MvnGraph, violations []mvn.Violation, rules []string) {
+	param := mvn.ReportParam{Poms: g.ModulePoms(), Rules: rules}
+	if *FlagPom != "" {
+		param.Pom = *FlagPom
+		if fi, err := os.Stat(*FlagPom); err == nil && fi.IsDir() {
+			param.Pom = filepath.Join(*FlagPom, "pom.xml")
+		}
 	}
+	format := mvn.FormatSARIF
+	if *FlagReport == ReportJUnit {
+		format = mvn.FormatJUnit
+	}
+	b, err := format(violations, param)
+	if err != nil {
+		panic(err)
+	}
+	writeReport(b)
+}
+
+// Write report to the output file or stdout.
+func writeReport(b []byte) {
+	if *FlagOutput == "" {
+		fmt.Println(string(b))
+		return
+	}
+	if err := os.WriteFile(*FlagOutput, b, 0644); err != nil {
+		panic(err)
+	}
+	fmt.Fprintf(os.Stderr, "Report written to: %s\n", *FlagOutput)
+}
 
+// Draw the graph and open the generated file.
+func draw(g *mvn.MvnGraph) {
 	g.Dpi = *FlagDpi
 	fmt.Printf("Graph built, dpi: %s, total %d nodes, %d edges\n", g.Dpi, g.NodeCount(), g.EdgeCount())
 
-	p, err := graph.DotGen(g, graph.DotGenParam{
+	p, err := graph.DotGen(g.DGraph, graph.DotGenParam{
 		Format: *FlagFormat,
 	})
 	if err != nil {
@@ -92,3 +392,113 @@ func main() {
 		panic(err)
 	}
 }
+
+// Parse dependency tree from -file, stdin or -pom, return nil if there is nothing to process. -pom is resolved if no
+// dependency tree is found in stdin, e.g., stdin is an empty pipe.
+//
+// Output of dependency:tree may also be written in dot, graphml, tgf or json using -DoutputType, the format is detected
+// automatically. In verbose mode, dependencies omitted for conflict or duplicate are included if the tree is resolved
+// from -pom, the ones read from -file or stdin are included only if they are present in the output.
+func parseInput(verbose bool) (*mvn.MvnGraph, error) {
+	title := fmt.Sprintf("dependency graph %s", *FlagFile)
+	param := mvn.ReaderParam{
+		ProgressInterval: 100000,
+		Progress: func(p mvn.Progress) {
+			fmt.Fprintf(os.Stderr, "Parsed %d lines, %d nodes\n", p.Lines, p.Nodes)
+		},
+	}
+
+	// mvn dependency:tree output file
+	if *FlagFile != "" {
+		f, err := os.Open(*FlagFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return mvn.ParseReader(title, f, param)
+	}
+
+	// stdin
+	if stdinPiped() {
+		fmt.Fprintln(os.Stderr, "Reading from stdin")
+		g, err := mvn.ParseReader(title, os.Stdin, param)
+		if !errors.Is(err, mvn.ErrNoDependencyTree) || *FlagPom == "" {
+			return g, err
+		}
+		// nothing is piped, e.g., stdin is redirected from /dev/null by scripts
+		fmt.Fprintln(os.Stderr, "No dependency tree found in stdin, resolving -pom instead")
+	}
+
+	if *FlagPom != "" {
+		return parsePom(verbose, param)
+	}
+	return nil, nil
+}
+
+// Whether stdin is piped or redirected from a file.
+func stdinPiped() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && (fi.Mode()&os.ModeCharDevice) == 0
+}
+
+// Resolve dependency tree of -pom, in verbose mode it's resolved like mvn dependency:tree -Dverbose.
+//
+// In offline mode, if poms of any dependency are missing in the local repository, only dependencies declared in the
+// project and its modules are included.
+func parsePom(verbose bool, param mvn.ReaderParam) (*mvn.MvnGraph, error) {
+	title := fmt.Sprintf("dependency graph %s", *FlagPom)
+
+	// resolve dependencies using poms in local repository
+	if *FlagOffline {
+		g, err := mvn.ResolvePom(title, *FlagPom, mvn.PomParam{Repo: *FlagRepo, Verbose: verbose})
+		if !errors.Is(err, mvn.ErrPomNotFound) {
+			return g, err
+		}
+		fmt.Fprintf(os.Stderr, "%v, only dependencies declared in the project are included\n", err)
+		return mvn.ParsePom(title, *FlagPom, mvn.PomParam{Repo: *FlagRepo})
+	}
+
+	// parse stdout of mvn as it's being written
+	args := []string{"dependency:tree", "-f", *FlagPom}
+	if verbose {
+		args = append(args, "-Dverbose")
+	}
+	cmd := exec.Command("mvn", args...)
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	g, err := mvn.ParseReader(title, stdout, param)
+	if err != nil {
+		_, _ = io.Copy(io.Discard, stdout)
+		_ = cmd.Wait()
+		return nil, err
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("mvn dependency:tree failed, %w", err)
+	}
+	return g, nil
+}
+
+// Find subgraph of the reactor module by artifactId.
+func moduleSubgraph(g *mvn.MvnGraph, artifactId string) (*mvn.MvnGraph, error) {
+	for _, m := range g.Modules() {
+		if c, ok := g.Coordinate(m); ok && c.ArtifactId == artifactId {
+			return g.ModuleSubgraph(m)
+		}
+	}
+	return nil, fmt.Errorf("module %v not found", artifactId)
+}
+
+// Default local maven repository, i.e., ~/.m2/repository.
+func defaultRepo() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".m2", "repository")
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/curtisnewbie/grapher/parser/mvn"
+)
+
+func TestParsePomOffline(t *testing.T) {
+	pom, offline, repo, policy := *FlagPom, *FlagOffline, *FlagRepo, *FlagPolicy
+	t.Cleanup(func() {
+		*FlagPom, *FlagOffline, *FlagRepo, *FlagPolicy = pom, offline, repo, policy
+	})
+	*FlagPom, *FlagOffline, *FlagRepo = "../../testdata/pom/shop", true, "../../testdata/m2"
+
+	g, err := parsePom(false, mvn.ReaderParam{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Verbose() || len(g.Conflicts()) > 0 {
+		t.Fatal("conflicts should not be available without verbose mode")
+	}
+
+	// legacy-client brings in commons-codec 1.11, while legacy-util asks for 1.15
+	g, err = parsePom(true, mvn.ReaderParam{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conflicts := g.Conflicts()
+	if len(conflicts) != 1 || conflicts[0].ArtifactId != "commons-codec" {
+		t.Fatalf("commons-codec conflict should be found, %#v", conflicts)
+	}
+
+	*FlagPolicy = filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(*FlagPolicy, []byte(`{"dependencyConvergence": true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if checkPolicy(g) {
+		t.Fatal("dependencyConvergence should be violated")
+	}
+}
+
+func TestParseInputEmptyStdin(t *testing.T) {
+	pom, offline, repo, file, stdin := *FlagPom, *FlagOffline, *FlagRepo, *FlagFile, os.Stdin
+	t.Cleanup(func() {
+		*FlagPom, *FlagOffline, *FlagRepo, *FlagFile, os.Stdin = pom, offline, repo, file, stdin
+	})
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	w.Close()
+	os.Stdin = r
+	*FlagPom, *FlagOffline, *FlagRepo, *FlagFile = "../../testdata/pom/shop", true, "../../testdata/m2", ""
+
+	g, err := parseInput(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g == nil || len(g.Modules()) == 0 {
+		t.Fatal("-pom should be resolved if stdin is empty")
+	}
+}
+
+func TestParsePomOfflineDeclared(t *testing.T) {
+	pom, offline, repo := *FlagPom, *FlagOffline, *FlagRepo
+	t.Cleanup(func() {
+		*FlagPom, *FlagOffline, *FlagRepo = pom, offline, repo
+	})
+
+	// only the parent and the BOM are downloaded, poms of the dependencies are missing
+	*FlagPom, *FlagOffline, *FlagRepo = "../../testdata/pom/shop", true, t.TempDir()
+	for _, p := range []string{
+		"com/example/corp-parent/1/corp-parent-1.pom",
+		"com/example/platform-bom/2.0/platform-bom-2.0.pom",
+	} {
+		b, err := os.ReadFile(filepath.Join("../../testdata/m2", p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(*FlagRepo, p)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(*FlagRepo, p), b, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	g, err := parsePom(true, mvn.ReaderParam{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.FindArtifact("com.example", "legacy-client")) == 0 {
+		t.Fatal("declared dependency legacy-client should be included")
+	}
+	if len(g.FindArtifact("com.example", "legacy-util")) > 0 {
+		t.Fatal("transitive dependency legacy-util should not be included")
+	}
+}
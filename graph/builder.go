@@ -1,22 +1,42 @@
 package graph
 
 import (
+	"fmt"
+	"sort"
+	"strconv"
 	"sync"
 )
 
+// Policy for key collisions when merging builders.
+type MergePolicy int
+
+const (
+	MergeKeepExisting MergePolicy = iota // keep the existing nodes and edges, this is the default.
+	MergeReplace                         // replace the existing nodes and edges, node ids are preserved.
+	MergeFail                            // fail the merge if any key collides, nothing is merged.
+)
+
 // Builder that manages keys, nodes and ids.
 //
 // This builder is thread-safe.
+//
+// Nodes and edges are always emitted in the order they are added.
 type KNodeGraphBuilder struct {
 	mu         sync.RWMutex
 	idCnt      int
+	edgeCnt    int
 	keyedNodes map[string]Node
-	keyedEdges map[string]map[string]string
+	keyedEdges map[string]map[string]kedge
 
-	// key of last added node, doesn't support concurrent use
+	// key of last added node, doesn't support concurrent use, see Cursor
 	lastAdded string
 }
 
+type kedge struct {
+	seq  int // insertion order
+	edge DEdge
+}
+
 func (b *KNodeGraphBuilder) BuildDGraph(title string) (*DGraph, error) {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
@@ -76,16 +96,20 @@ func (b *KNodeGraphBuilder) SAdd(k string, label string) *KNodeGraphBuilder {
 }
 
 // Add node to builder using given key and connect to last added node, this method should not be called concurrently.
+//
+// Use Cursor instead to build chains concurrently.
 func (b *KNodeGraphBuilder) SAddConnectLast(k string, label string) *KNodeGraphBuilder {
-	last := b.lastAdded // not protected by lock
+	last := b.getLastAdded()
 	b.SAdd(k, label)
 	b.Connect(last, k)
 	return b
 }
 
 // Add node to builder using given key and connect to last added node, this method should not be called concurrently.
+//
+// Use Cursor instead to build chains concurrently.
 func (b *KNodeGraphBuilder) SAddShapeConnectLast(k string, label string, shape string) *KNodeGraphBuilder {
-	last := b.lastAdded // not protected by lock
+	last := b.getLastAdded()
 	b.SAddShape(k, label, shape)
 	b.Connect(last, k)
 	return b
@@ -93,29 +117,232 @@ func (b *KNodeGraphBuilder) SAddShapeConnectLast(k string, label string, shape s
 
 // Mark the node identified by the given key as the last added node, this method should not be called concurrently.
 func (b *KNodeGraphBuilder) SetLastAdded(k string) *KNodeGraphBuilder {
-	b.lastAdded = k // not protected by lock
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastAdded = k
 	return b
 }
 
+func (b *KNodeGraphBuilder) getLastAdded() string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.lastAdded
+}
+
+// Create a new Cursor that tracks its own last added node.
+func (b *KNodeGraphBuilder) Cursor() *Cursor {
+	return &Cursor{b: b}
+}
+
+// Create a new Cursor with the node identified by the given key as its last added node.
+func (b *KNodeGraphBuilder) CursorAt(k string) *Cursor {
+	return &Cursor{b: b, last: k}
+}
+
 func (b *KNodeGraphBuilder) SConnect(k1 string, k2 string, label string) *KNodeGraphBuilder {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 	s, ok := b.keyedEdges[k1]
-	if ok {
-		{
-			_, ok1 := s[k2]
-			if !ok1 || label != "" {
-				s[k2] = label
-			}
-		}
-	} else {
-		s = map[string]string{}
-		s[k2] = label
+	if !ok {
+		s = map[string]kedge{}
 		b.keyedEdges[k1] = s
 	}
+	ed, ok := s[k2]
+	if !ok {
+		b.edgeCnt++
+		s[k2] = kedge{seq: b.edgeCnt, edge: DEdge{Label: label}}
+	} else if label != "" {
+		ed.edge.Label = label
+		s[k2] = ed
+	}
+	return b
+}
+
+// Connect the two nodes using the given edge, the edge's label, tooltip, style and attributes replace the existing ones.
+//
+// DEdge.FromId and DEdge.ToId are always ignored.
+func (b *KNodeGraphBuilder) ConnectEdge(k1 string, k2 string, edge DEdge) *KNodeGraphBuilder {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b._connectEdge(k1, k2, edge)
 	return b
 }
 
+func (b *KNodeGraphBuilder) _connectEdge(k1 string, k2 string, edge DEdge) {
+	edge.FromId = 0
+	edge.ToId = 0
+	s, ok := b.keyedEdges[k1]
+	if !ok {
+		s = map[string]kedge{}
+		b.keyedEdges[k1] = s
+	}
+	ed, ok := s[k2]
+	if !ok {
+		b.edgeCnt++
+		ed.seq = b.edgeCnt
+	}
+	ed.edge = edge
+	s[k2] = ed
+}
+
+// Update the edge connecting the two nodes, return false if the nodes are not connected.
+func (b *KNodeGraphBuilder) UpdateEdge(k1 string, k2 string, f func(e *DEdge)) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ed, ok := b.keyedEdges[k1][k2]
+	if !ok {
+		return false
+	}
+	f(&ed.edge)
+	b.keyedEdges[k1][k2] = ed
+	return true
+}
+
+// Remove the edge connecting the two nodes, return false if the nodes are not connected.
+func (b *KNodeGraphBuilder) Disconnect(k1 string, k2 string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s, ok := b.keyedEdges[k1]
+	if !ok {
+		return false
+	}
+	if _, ok := s[k2]; !ok {
+		return false
+	}
+	delete(s, k2)
+	if len(s) < 1 {
+		delete(b.keyedEdges, k1)
+	}
+	return true
+}
+
+// Remove node identified by the given key as well as the edges connected to it, return false if the key doesn't exist.
+//
+// The id of the removed node is never reused.
+func (b *KNodeGraphBuilder) Remove(k string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.keyedNodes[k]; !ok {
+		return false
+	}
+	delete(b.keyedNodes, k)
+	delete(b.keyedEdges, k)
+	for k1, s := range b.keyedEdges {
+		delete(s, k)
+		if len(s) < 1 {
+			delete(b.keyedEdges, k1)
+		}
+	}
+	if b.lastAdded == k {
+		b.lastAdded = ""
+	}
+	return true
+}
+
+// Update node identified by the given key, e.g., to change its label or shape, return false if the key doesn't exist.
+//
+// Node.Id is always preserved.
+func (b *KNodeGraphBuilder) Update(k string, f func(n *Node)) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n, ok := b.keyedNodes[k]
+	if !ok {
+		return false
+	}
+	id := n.Id
+	f(&n)
+	n.Id = id
+	b.keyedNodes[k] = n
+	return true
+}
+
+// Merge nodes and edges of the other builder into this builder.
+//
+// Nodes from the other builder are assigned new ids, key collisions are resolved based on the given policy.
+func (b *KNodeGraphBuilder) MergeFrom(other *KNodeGraphBuilder, policy MergePolicy) error {
+	if other == b {
+		return nil
+	}
+
+	other.mu.RLock()
+	keys := make([]string, 0, len(other.keyedNodes))
+	nodes := make(map[string]Node, len(other.keyedNodes))
+	for k, n := range other.keyedNodes {
+		keys = append(keys, k)
+		nodes[k] = n
+	}
+	type keyedEdge struct {
+		k1, k2 string
+		ke     kedge
+	}
+	edges := []keyedEdge{}
+	for k1, s := range other.keyedEdges {
+		for k2, ke := range s {
+			edges = append(edges, keyedEdge{k1: k1, k2: k2, ke: ke})
+		}
+	}
+	other.mu.RUnlock()
+
+	sort.Slice(keys, func(i, j int) bool { return nodes[keys[i]].Id < nodes[keys[j]].Id })
+	sort.Slice(edges, func(i, j int) bool { return edges[i].ke.seq < edges[j].ke.seq })
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if policy == MergeFail {
+		for _, k := range keys {
+			if _, ok := b.keyedNodes[k]; ok {
+				return fmt.Errorf("failed to merge builder, key '%v' already exists", k)
+			}
+		}
+	}
+
+	for _, k := range keys {
+		n := nodes[k]
+		if prev, ok := b.keyedNodes[k]; ok {
+			if policy == MergeReplace {
+				n.Id = prev.Id
+				b.keyedNodes[k] = n
+			}
+			continue
+		}
+		b.idCnt++
+		n.Id = b.idCnt
+		b.keyedNodes[k] = n
+	}
+
+	for _, ed := range edges {
+		if _, ok := b.keyedEdges[ed.k1][ed.k2]; ok && policy != MergeReplace {
+			continue
+		}
+		b._connectEdge(ed.k1, ed.k2, ed.ke.edge)
+	}
+	return nil
+}
+
+// Load nodes and edges of the DGraph into this builder, key collisions are resolved based on the given policy.
+//
+// Each node is keyed by the given key func, if key func is nil, the node's id is used as the key.
+func (b *KNodeGraphBuilder) LoadDGraph(g *DGraph, key func(n Node) string, policy MergePolicy) error {
+	if key == nil {
+		key = func(n Node) string { return strconv.Itoa(n.Id) }
+	}
+
+	tmp := NewKNodeGraphBuilder()
+	keys := map[int]string{}
+	for _, n := range g.Nodes() {
+		k := key(n)
+		if _, ok := tmp.Add(k, n); !ok {
+			return fmt.Errorf("failed to load graph, duplicate key '%v' for node %v", k, n.Id)
+		}
+		keys[n.Id] = k
+	}
+	for _, ed := range g.Edges() {
+		tmp.ConnectEdge(keys[ed.FromId], keys[ed.ToId], ed)
+	}
+	return b.MergeFrom(&tmp, policy)
+}
+
 func (b *KNodeGraphBuilder) Connect(k1 string, k2 string) *KNodeGraphBuilder {
 	b.SConnect(k1, k2, "")
 	return b
@@ -132,6 +359,7 @@ func (b *KNodeGraphBuilder) _nodes() []Node {
 	for k := range b.keyedNodes {
 		nodes = append(nodes, b.keyedNodes[k])
 	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Id < nodes[j].Id })
 	return nodes
 }
 
@@ -142,21 +370,34 @@ func (b *KNodeGraphBuilder) Edges() []DEdge {
 }
 
 func (b *KNodeGraphBuilder) _edges() []DEdge {
-	edges := make([]DEdge, 0, len(b.keyedEdges))
+	type seqEdge struct {
+		seq  int
+		edge DEdge
+	}
+	sorted := make([]seqEdge, 0, len(b.keyedEdges))
 	for k, ed := range b.keyedEdges {
 		kn, ok := b._find(k)
 		if !ok {
 			continue
 		}
 		// k -> nb
-		for nb, edgeLabel := range ed {
+		for nb, ke := range ed {
 			nn, ok := b._find(nb)
 			if !ok {
 				continue
 			}
-			edges = append(edges, DEdge{FromId: kn.Id, ToId: nn.Id, Label: edgeLabel})
+			edge := ke.edge
+			edge.FromId = kn.Id
+			edge.ToId = nn.Id
+			sorted = append(sorted, seqEdge{seq: ke.seq, edge: edge})
 		}
 	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].seq < sorted[j].seq })
+
+	edges := make([]DEdge, 0, len(sorted))
+	for _, se := range sorted {
+		edges = append(edges, se.edge)
+	}
 	return edges
 }
 
@@ -164,6 +405,6 @@ func NewKNodeGraphBuilder() KNodeGraphBuilder {
 	return KNodeGraphBuilder{
 		idCnt:      0,
 		keyedNodes: map[string]Node{},
-		keyedEdges: map[string]map[string]string{},
+		keyedEdges: map[string]map[string]kedge{},
 	}
 }
@@ -1,6 +1,7 @@
 package graph
 
 import (
+	"fmt"
 	"sync"
 	"testing"
 
@@ -69,3 +70,152 @@ func TestAsyncKNodeGraphBuilder(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestKNodeGraphBuilderDeterministic(t *testing.T) {
+	build := func() string {
+		bu := NewKNodeGraphBuilder()
+		for i := 0; i < 30; i++ {
+			bu.SAdd(fmt.Sprintf("svc-%d", i), fmt.Sprintf("service %d", i))
+		}
+		for i := 29; i > 0; i-- {
+			bu.SConnect(fmt.Sprintf("svc-%d", i), fmt.Sprintf("svc-%d", i-1), fmt.Sprintf("call %d", i))
+			bu.Connect(fmt.Sprintf("svc-%d", i), fmt.Sprintf("svc-%d", (i*7)%30))
+		}
+		g, err := bu.BuildDGraph("test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := g.SDraw()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	first := build()
+	for i := 0; i < 10; i++ {
+		if s := build(); s != first {
+			t.Fatalf("output is not deterministic, expected:\n%v\nactual:\n%v", first, s)
+		}
+	}
+
+	bu := NewKNodeGraphBuilder()
+	bu.SAdd("a", "a").SAdd("b", "b").SAdd("c", "c")
+	bu.Connect("c", "a").Connect("a", "b").Connect("b", "c")
+	edges := bu.Edges()
+	if len(edges) != 3 || edges[0].FromId != 3 || edges[1].FromId != 1 || edges[2].FromId != 2 {
+		t.Fatalf("edges should be in insertion order, %+v", edges)
+	}
+}
+
+func TestKNodeGraphBuilderEdit(t *testing.T) {
+	bu := NewKNodeGraphBuilder()
+	bu.SAdd("fstore", "fstore").SAdd("vfm", "vfm").SAdd("uvault", "user-vault")
+	bu.Connect("fstore", "vfm").Connect("uvault", "vfm").Connect("fstore", "uvault")
+	bu.ConnectEdge("vfm", "uvault", DEdge{Label: "rpc", Tooltip: "vfm reads user info", Attrs: map[string]string{"protocol": "http"}})
+
+	if !bu.Update("uvault", func(n *Node) { n.Label = "user-vault v2"; n.Id = 100 }) {
+		t.Fatal("should update uvault")
+	}
+	if n, _ := bu.Find("uvault"); n.Label != "user-vault v2" || n.Id != 3 {
+		t.Fatalf("unexpected node, %#v", n)
+	}
+	if !bu.Disconnect("fstore", "vfm") || bu.Disconnect("fstore", "vfm") {
+		t.Fatal("should disconnect fstore -> vfm once")
+	}
+	if !bu.Remove("fstore") || bu.Remove("fstore") {
+		t.Fatal("should remove fstore once")
+	}
+
+	edges := bu.Edges()
+	if len(edges) != 2 {
+		t.Fatalf("should have 2 edges, %+v", edges)
+	}
+	if ed := edges[1]; ed.Tooltip != "vfm reads user info" || ed.Attrs["protocol"] != "http" {
+		t.Fatalf("edge attributes should be kept, %+v", ed)
+	}
+
+	other := NewKNodeGraphBuilder()
+	other.SAdd("vfm", "vfm v2").SAdd("gateway", "gateway")
+	other.SConnect("gateway", "vfm", "proxy")
+
+	if err := bu.MergeFrom(&other, MergeFail); err == nil {
+		t.Fatal("should fail on key collision")
+	}
+	if err := bu.MergeFrom(&other, MergeKeepExisting); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := bu.Find("vfm"); n.Label != "vfm" {
+		t.Fatalf("should keep existing vfm, %#v", n)
+	}
+	if err := bu.MergeFrom(&other, MergeReplace); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := bu.Find("vfm"); n.Label != "vfm v2" || n.Id != 2 {
+		t.Fatalf("should replace vfm, %#v", n)
+	}
+	if n, ok := bu.Find("gateway"); !ok || n.Id != 4 {
+		t.Fatalf("should merge gateway, %#v", n)
+	}
+
+	g, err := bu.BuildDGraph("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.NodeCount() != 3 || g.EdgeCount() != 3 {
+		t.Fatalf("unexpected graph, nodes: %v, edges: %v", g.NodeCount(), g.EdgeCount())
+	}
+
+	loaded := NewKNodeGraphBuilder()
+	if err := loaded.LoadDGraph(g, func(n Node) string { return n.Label }, MergeFail); err != nil {
+		t.Fatal(err)
+	}
+	loaded.Remove("gateway")
+	lg, err := loaded.BuildDGraph("loaded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lg.NodeCount() != 2 || lg.EdgeCount() != 2 {
+		t.Fatalf("unexpected graph, nodes: %v, edges: %v", lg.NodeCount(), lg.EdgeCount())
+	}
+}
+
+func TestKNodeGraphBuilderCursor(t *testing.T) {
+	bu := NewKNodeGraphBuilder()
+	bu.SAdd("gateway", "gateway")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		worker := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := bu.CursorAt("gateway")
+			for j := 0; j < 5; j++ {
+				c.SAddConnectLast(fmt.Sprintf("w%d-s%d", worker, j), fmt.Sprintf("worker %d step %d", worker, j))
+			}
+			c.SConnectLast("gateway", "done")
+		}()
+	}
+	wg.Wait()
+
+	g, err := bu.BuildDGraph("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.NodeCount() != 101 || g.EdgeCount() != 120 {
+		t.Fatalf("unexpected graph, nodes: %v, edges: %v", g.NodeCount(), g.EdgeCount())
+	}
+
+	// each step should only be connected to the next step of the same worker
+	for i := 0; i < 20; i++ {
+		for j := 0; j < 4; j++ {
+			from, _ := bu.Find(fmt.Sprintf("w%d-s%d", i, j))
+			to, _ := bu.Find(fmt.Sprintf("w%d-s%d", i, j+1))
+			eds := g.OutEdges(from.Id)
+			if len(eds) != 1 || eds[0].ToId != to.Id {
+				t.Fatalf("crossed wires at worker %d step %d, %+v", i, j, eds)
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package graph
+
+// Cursor that chains nodes into a KNodeGraphBuilder, each Cursor tracks its own last added node.
+//
+// A Cursor should only be used by one goroutine, but multiple cursors can build chains into the same builder
+// concurrently, e.g., one cursor for each worker.
+type Cursor struct {
+	b    *KNodeGraphBuilder
+	last string
+}
+
+// Key of the last added node of this cursor.
+func (c *Cursor) LastAdded() string {
+	return c.last
+}
+
+// Mark the node identified by the given key as the last added node of this cursor.
+func (c *Cursor) SetLastAdded(k string) *Cursor {
+	c.last = k
+	return c
+}
+
+// Add node using given key without connecting it, the node becomes the last added node of this cursor.
+//
+// If the key exists, the previous node is kept.
+func (c *Cursor) SAdd(k string, label string) *Cursor {
+	return c.SAddShape(k, label, "")
+}
+
+// Add node using given key and shape without connecting it, the node becomes the last added node of this cursor.
+//
+// If the key exists, the previous node is kept.
+func (c *Cursor) SAddShape(k string, label string, shape string) *Cursor {
+	c.b.Add(k, Node{Label: label, Shape: shape})
+	c.last = k
+	return c
+}
+
+// Add node using given key and connect to the last added node of this cursor.
+func (c *Cursor) SAddConnectLast(k string, label string) *Cursor {
+	return c.SAddShapeConnectLast(k, label, "")
+}
+
+// Add node using given key and shape, and connect to the last added node of this cursor.
+func (c *Cursor) SAddShapeConnectLast(k string, label string, shape string) *Cursor {
+	last := c.last
+	c.SAddShape(k, label, shape)
+	if last != "" {
+		c.b.Connect(last, k)
+	}
+	return c
+}
+
+// Connect the last added node of this cursor to the existing node identified by the given key using the edge label,
+// the node becomes the last added node of this cursor.
+func (c *Cursor) SConnectLast(k string, edgeLabel string) *Cursor {
+	if c.last != "" {
+		c.b.SConnect(c.last, k, edgeLabel)
+	}
+	c.last = k
+	return c
+}
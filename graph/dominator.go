@@ -0,0 +1,224 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Dominator tree of the nodes reachable from a root.
+//
+// Node a dominates node b if every path from the root to b goes through a, i.e., b disappears if a is removed. Nodes
+// dominated by a node are the ones exclusively reachable through it, which can't be told by Subgraph or TreeShake
+// when nodes are shared by multiple paths.
+type DominatorTree struct {
+	title     string
+	displayId bool
+	root      int
+	idom      map[int]int   // id -> immediate dominator, the root is dominated by itself
+	children  map[int][]int // id -> nodes immediately dominated by it
+	nodes     []Node        // reachable nodes in the order of nodes in the graph
+	pos       map[int]int   // id -> index in nodes
+	counts    map[int]int   // id -> number of nodes dominated by it, including itself
+}
+
+// Compute dominator tree of the nodes reachable from the root using the algorithm by Cooper, Harvey and Kennedy
+// (A Simple, Fast Dominance Algorithm).
+//
+// Only edges that f returns true are followed, all edges are followed if f is nil.
+func (d *DGraph) Dominators(rootId int, f func(ed DEdge) bool) (*DominatorTree, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if _, ok := d.nodeMap[rootId]; !ok {
+		return nil, fmt.Errorf("rootId %v not found", rootId)
+	}
+	follow := func(ed DEdge) bool {
+		if _, ok := d.nodeMap[ed.ToId]; !ok {
+			return false
+		}
+		return f == nil || f(ed)
+	}
+
+	// postorder of the depth-first search from root
+	type frame struct {
+		id   int
+		next int
+	}
+	postorder := []int{}
+	po := map[int]int{}
+	visited := map[int]struct{}{rootId: {}}
+	preds := map[int][]int{}
+	stack := []frame{{id: rootId}}
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		edges := d.nodeEdges[top.id]
+		if top.next >= len(edges) {
+			po[top.id] = len(postorder)
+			postorder = append(postorder, top.id)
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		ed := edges[top.next]
+		top.next++
+		if !follow(ed) {
+			continue
+		}
+		preds[ed.ToId] = append(preds[ed.ToId], ed.FromId)
+		if _, ok := visited[ed.ToId]; ok {
+			continue
+		}
+		visited[ed.ToId] = struct{}{}
+		stack = append(stack, frame{id: ed.ToId})
+	}
+
+	idom := map[int]int{rootId: rootId}
+	intersect := func(a int, b int) int {
+		for a != b {
+			for po[a] < po[b] {
+				a = idom[a]
+			}
+			for po[b] < po[a] {
+				b = idom[b]
+			}
+		}
+		return a
+	}
+	for changed := true; changed; {
+		changed = false
+		// reverse postorder, the root is the last one
+		for i := len(postorder) - 2; i >= 0; i-- {
+			b := postorder[i]
+			nd, found := 0, false
+			for _, p := range preds[b] {
+				if _, ok := idom[p]; !ok {
+					continue
+				}
+				if !found {
+					nd, found = p, true
+				} else {
+					nd = intersect(p, nd)
+				}
+			}
+			if prev, ok := idom[b]; found && (!ok || prev != nd) {
+				idom[b] = nd
+				changed = true
+			}
+		}
+	}
+
+	t := &DominatorTree{
+		title:     d.title,
+		displayId: d.DisplayId,
+		root:      rootId,
+		idom:      idom,
+		children:  map[int][]int{},
+		nodes:     []Node{},
+		pos:       map[int]int{},
+		counts:    map[int]int{},
+	}
+	for _, n := range d.nodes {
+		if _, ok := idom[n.Id]; !ok {
+			continue
+		}
+		n.Attrs = copyAttrs(n.Attrs)
+		t.pos[n.Id] = len(t.nodes)
+		t.nodes = append(t.nodes, n)
+		if n.Id != rootId {
+			t.children[idom[n.Id]] = append(t.children[idom[n.Id]], n.Id)
+		}
+	}
+	// a node always comes before its dominators in postorder
+	for _, id := range postorder {
+		t.counts[id] = 1
+		for _, c := range t.children[id] {
+			t.counts[id] += t.counts[c]
+		}
+	}
+	return t, nil
+}
+
+// Root of the dominator tree.
+func (t *DominatorTree) Root() int {
+	return t.root
+}
+
+// Whether the node is reachable from the root.
+func (t *DominatorTree) Reachable(id int) bool {
+	_, ok := t.idom[id]
+	return ok
+}
+
+// Immediate dominator of the node, false if the node is the root or it's not reachable from the root.
+func (t *DominatorTree) Idom(id int) (int, bool) {
+	v, ok := t.idom[id]
+	if !ok || id == t.root {
+		return 0, false
+	}
+	return v, true
+}
+
+// Whether node a dominates node b, a node dominates itself.
+func (t *DominatorTree) Dominates(a int, b int) bool {
+	if !t.Reachable(a) || !t.Reachable(b) {
+		return false
+	}
+	for b != t.root {
+		if a == b {
+			return true
+		}
+		b = t.idom[b]
+	}
+	return a == t.root
+}
+
+// Nodes dominated by the node, including itself, in the order of nodes in the graph. These are the nodes that
+// disappear if the node is removed, i.e., nodes that are only reachable through it.
+func (t *DominatorTree) Exclusive(id int) []int {
+	if !t.Reachable(id) {
+		return []int{}
+	}
+	ids := []int{}
+	stack := []int{id}
+	for len(stack) > 0 {
+		pop := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		ids = append(ids, pop)
+		stack = append(stack, t.children[pop]...)
+	}
+	sort.Slice(ids, func(i, j int) bool { return t.pos[ids[i]] < t.pos[ids[j]] })
+	return ids
+}
+
+// Number of nodes dominated by the node, including itself, 0 if the node is not reachable from the root.
+func (t *DominatorTree) ExclusiveCount(id int) int {
+	return t.counts[id]
+}
+
+// Render the dominator tree as a new graph, each node is connected from its immediate dominator.
+//
+// Nodes are copied from the original graph when the tree is computed, the number of nodes dominated by each node is
+// appended to its tooltip.
+func (t *DominatorTree) Graph() (*DGraph, error) {
+	nodes := make([]Node, 0, len(t.nodes))
+	for _, n := range t.nodes {
+		n.Attrs = copyAttrs(n.Attrs)
+		excl := fmt.Sprintf("exclusive: %d", t.counts[n.Id])
+		if n.Tooltip != "" {
+			n.Tooltip += ", " + excl
+		} else {
+			n.Tooltip = excl
+		}
+		nodes = append(nodes, n)
+	}
+	edges := []DEdge{}
+	for _, n := range t.nodes {
+		if p, ok := t.Idom(n.Id); ok {
+			edges = append(edges, DEdge{FromId: p, ToId: n.Id})
+		}
+	}
+	g, err := NewDGraph(t.title+" (dominator tree)", nodes, edges)
+	if err == nil {
+		g.DisplayId = t.displayId
+	}
+	return g, err
+}
@@ -0,0 +1,85 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDominators(t *testing.T) {
+	nodes := []Node{}
+	for i, l := range []string{"app", "web", "db", "core", "util", "log", "json", "orphan"} {
+		nodes = append(nodes, Node{Id: i + 1, Label: l})
+	}
+	edges := []DEdge{
+		{FromId: 1, ToId: 2},
+		{FromId: 1, ToId: 3},
+		{FromId: 2, ToId: 4},
+		{FromId: 3, ToId: 4},
+		{FromId: 4, ToId: 5},
+		{FromId: 5, ToId: 6},
+		{FromId: 2, ToId: 7},
+		{FromId: 7, ToId: 2},
+		{FromId: 8, ToId: 4},
+	}
+	g, err := NewDGraph("mygraph", nodes, edges)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dt, err := g.Dominators(1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idoms := map[int]int{2: 1, 3: 1, 4: 1, 5: 4, 6: 5, 7: 2}
+	for id, want := range idoms {
+		if v, ok := dt.Idom(id); !ok || v != want {
+			t.Fatalf("idom of %v should be %v, %v", id, want, v)
+		}
+	}
+	if _, ok := dt.Idom(1); ok {
+		t.Fatal("root should not have idom")
+	}
+	if dt.Reachable(8) || dt.ExclusiveCount(8) != 0 {
+		t.Fatal("orphan should not be reachable")
+	}
+	// core is shared by web and db, removing web only removes json
+	if ids := dt.Exclusive(2); !reflect.DeepEqual(ids, []int{2, 7}) {
+		t.Fatalf("unexpected exclusive nodes of web, %v", ids)
+	}
+	if ids := dt.Exclusive(4); !reflect.DeepEqual(ids, []int{4, 5, 6}) {
+		t.Fatalf("unexpected exclusive nodes of core, %v", ids)
+	}
+	if dt.ExclusiveCount(1) != 7 || dt.ExclusiveCount(4) != 3 || dt.ExclusiveCount(6) != 1 {
+		t.Fatalf("unexpected counts, %v, %v, %v", dt.ExclusiveCount(1), dt.ExclusiveCount(4), dt.ExclusiveCount(6))
+	}
+	if !dt.Dominates(4, 6) || !dt.Dominates(1, 6) || !dt.Dominates(6, 6) || dt.Dominates(2, 4) || dt.Dominates(8, 4) {
+		t.Fatal("unexpected dominance")
+	}
+
+	// without db -> core, core is only reachable through web
+	dt, err = g.Dominators(1, func(ed DEdge) bool { return !(ed.FromId == 3 && ed.ToId == 4) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := dt.Exclusive(2); !reflect.DeepEqual(ids, []int{2, 4, 5, 6, 7}) {
+		t.Fatalf("unexpected exclusive nodes of web, %v", ids)
+	}
+
+	tree, err := dt.Graph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.NodeCount() != 7 || tree.EdgeCount() != 6 {
+		t.Fatalf("unexpected dominator tree, nodes: %v, edges: %v", tree.NodeCount(), tree.EdgeCount())
+	}
+	if n, _ := tree.Node(2); n.Tooltip != "exclusive: 5" {
+		t.Fatalf("unexpected tooltip, %#v", n)
+	}
+	if !tree.Connected(2, 6) || tree.Connected(3, 4) {
+		t.Fatal("unexpected edges in dominator tree")
+	}
+
+	if _, err := g.Dominators(100, nil); err == nil {
+		t.Fatal("missing root should be rejected")
+	}
+}
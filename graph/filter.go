@@ -0,0 +1,434 @@
+package graph
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	FilterFieldLabel   = "label"
+	FilterFieldTooltip = "tooltip"
+	FilterFieldShape   = "shape"
+	FilterFieldId      = "id"
+	FilterFieldDepth   = "depth"  // shortest distance from any node without incoming edges
+	FilterFieldInDeg   = "indeg"  // number of incoming edges
+	FilterFieldOutDeg  = "outdeg" // number of outgoing edges
+)
+
+// Compiled filter expression.
+//
+// A filter expression is made of terms combined with 'and', 'or', 'not' and parentheses, adjacent terms
+// are implicitly joined with 'and'. Supported terms are:
+//
+//	jackson              label contains 'jackson', '*' and '?' wildcards are supported as well
+//	group:com.fasterxml* field matches the glob pattern
+//	artifact~^jackson-   field matches the regular expression
+//	depth<=2             field compared with the value, one of =, !=, <, <=, >, >=
+//
+// Fields can be label, tooltip, shape, id, depth, indeg, outdeg or any key in Node.Attrs. Values that contain
+// whitespaces or parentheses should be quoted, e.g., label:"a b".
+//
+// e.g.,
+//
+//	group:com.fasterxml* and not scope:test
+//	(indeg>1 or depth<2) and label~"core|annotations"
+type Filter struct {
+	src  string
+	expr filterExpr
+}
+
+// Compile the filter to a predicate that can be used by DGraph.TreeShake, DGraph.FindNode, DGraph.Highlight, etc.
+//
+// The depth and degree of each node are computed when the predicate is compiled, the given graph can be nil
+// if the filter doesn't use them.
+func (f *Filter) Predicate(g *DGraph) func(n Node) bool {
+	c := newFilterCtx(g)
+	return func(n Node) bool { return f.expr.match(c, n) }
+}
+
+func (f *Filter) String() string {
+	return f.src
+}
+
+// Parse filter expression, see Filter.
+func ParseFilter(s string) (*Filter, error) {
+	toks, err := lexFilter(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter expression '%v', %w", s, err)
+	}
+	if len(toks) < 1 {
+		return nil, fmt.Errorf("invalid filter expression '%v', expression is empty", s)
+	}
+	p := &filterParser{toks: toks}
+	e, err := p.parseOr()
+	if err == nil && p.pos < len(p.toks) {
+		err = fmt.Errorf("unexpected '%v' at %d", p.toks[p.pos].text, p.toks[p.pos].pos)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter expression '%v', %w", s, err)
+	}
+	return &Filter{src: s, expr: e}, nil
+}
+
+type filterCtx struct {
+	depth  map[int]int
+	inDeg  map[int]int
+	outDeg map[int]int
+}
+
+func newFilterCtx(g *DGraph) *filterCtx {
+	c := &filterCtx{depth: map[int]int{}, inDeg: map[int]int{}, outDeg: map[int]int{}}
+	if g == nil {
+		return c
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	for _, ed := range g.edges {
+		c.outDeg[ed.FromId]++
+		c.inDeg[ed.ToId]++
+	}
+	queue := []int{}
+	for _, n := range g.nodes {
+		if c.inDeg[n.Id] == 0 {
+			c.depth[n.Id] = 0
+			queue = append(queue, n.Id)
+		}
+	}
+	for len(queue) > 0 {
+		pop := queue[0]
+		queue = queue[1:]
+		for _, ed := range g.nodeEdges[pop] {
+			if _, ok := c.depth[ed.ToId]; ok {
+				continue
+			}
+			c.depth[ed.ToId] = c.depth[pop] + 1
+			queue = append(queue, ed.ToId)
+		}
+	}
+	return c
+}
+
+// resolve value of the field, return false if the node doesn't have the field.
+func (c *filterCtx) field(n Node, f string) (string, bool) {
+	switch f {
+	case FilterFieldLabel:
+		return n.Label, true
+	case FilterFieldTooltip:
+		return n.Tooltip, true
+	case FilterFieldShape:
+		return n.Shape, true
+	case FilterFieldId:
+		return strconv.Itoa(n.Id), true
+	case FilterFieldDepth:
+		v, ok := c.depth[n.Id]
+		if !ok {
+			return "", false
+		}
+		return strconv.Itoa(v), true
+	case FilterFieldInDeg:
+		return strconv.Itoa(c.inDeg[n.Id]), true
+	case FilterFieldOutDeg:
+		return strconv.Itoa(c.outDeg[n.Id]), true
+	}
+	v, ok := n.Attrs[f]
+	return v, ok
+}
+
+type filterExpr interface {
+	match(c *filterCtx, n Node) bool
+}
+
+type filterAnd struct{ l, r filterExpr }
+
+func (e filterAnd) match(c *filterCtx, n Node) bool { return e.l.match(c, n) && e.r.match(c, n) }
+
+type filterOr struct{ l, r filterExpr }
+
+func (e filterOr) match(c *filterCtx, n Node) bool { return e.l.match(c, n) || e.r.match(c, n) }
+
+type filterNot struct{ e filterExpr }
+
+func (e filterNot) match(c *filterCtx, n Node) bool { return !e.e.match(c, n) }
+
+// label contains the value
+type filterContains struct{ value string }
+
+func (e filterContains) match(c *filterCtx, n Node) bool { return strings.Contains(n.Label, e.value) }
+
+// field matches the glob pattern or the regular expression
+type filterPattern struct {
+	field string
+	re    *regexp.Regexp
+}
+
+func (e filterPattern) match(c *filterCtx, n Node) bool {
+	v, ok := c.field(n, e.field)
+	return ok && e.re.MatchString(v)
+}
+
+// field compared with the value, numerically if both are numbers
+type filterCompare struct {
+	field string
+	op    string
+	value string
+}
+
+func (e filterCompare) match(c *filterCtx, n Node) bool {
+	v, ok := c.field(n, e.field)
+	if !ok {
+		return false
+	}
+	cmp := strings.Compare(v, e.value)
+	if fv, err := strconv.ParseFloat(v, 64); err == nil {
+		if fe, err := strconv.ParseFloat(e.value, 64); err == nil {
+			switch {
+			case fv < fe:
+				cmp = -1
+			case fv > fe:
+				cmp = 1
+			default:
+				cmp = 0
+			}
+		}
+	}
+	switch e.op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+const (
+	filterTokWord = iota
+	filterTokTerm
+	filterTokLParen
+	filterTokRParen
+)
+
+type filterToken struct {
+	kind  int
+	pos   int
+	text  string // raw text
+	field string // field of term
+	op    string // operator of term
+	value string // unquoted value of term or word
+}
+
+var filterOps = []string{"!=", "<=", ">=", ":", "~", "=", "<", ">"}
+
+func lexFilter(s string) ([]filterToken, error) {
+	toks := []filterToken{}
+	rs := []rune(s)
+	i := 0
+	for i < len(rs) {
+		r := rs[i]
+		if unicode.IsSpace(r) {
+			i++
+			continue
+		}
+		if r == '(' {
+			toks = append(toks, filterToken{kind: filterTokLParen, pos: i, text: "("})
+			i++
+			continue
+		}
+		if r == ')' {
+			toks = append(toks, filterToken{kind: filterTokRParen, pos: i, text: ")"})
+			i++
+			continue
+		}
+
+		start := i
+		tok := filterToken{kind: filterTokWord, pos: start}
+
+		// field name followed by an operator
+		j := i
+		for j < len(rs) && (unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j]) || rs[j] == '_' || rs[j] == '.' || rs[j] == '-') {
+			j++
+		}
+		if j > i {
+			rest := string(rs[j:])
+			for _, op := range filterOps {
+				if strings.HasPrefix(rest, op) {
+					tok.kind = filterTokTerm
+					tok.field = string(rs[i:j])
+					tok.op = op
+					i = j + len([]rune(op))
+					break
+				}
+			}
+		}
+
+		// value, may be partially quoted
+		val := strings.Builder{}
+		quoted := false
+		for i < len(rs) {
+			r := rs[i]
+			if quoted {
+				if r == '\\' && i+1 < len(rs) && rs[i+1] == '"' {
+					val.WriteRune('"')
+					i += 2
+					continue
+				}
+				if r == '"' {
+					quoted = false
+				} else {
+					val.WriteRune(r)
+				}
+				i++
+				continue
+			}
+			if r == '"' {
+				quoted = true
+				i++
+				continue
+			}
+			if unicode.IsSpace(r) || r == '(' || r == ')' {
+				break
+			}
+			val.WriteRune(r)
+			i++
+		}
+		if quoted {
+			return nil, fmt.Errorf("unterminated quote at %d", start)
+		}
+		tok.text = string(rs[start:i])
+		tok.value = val.String()
+		if tok.kind == filterTokTerm && tok.value == "" && tok.op != ":" && tok.op != "=" && tok.op != "!=" {
+			return nil, fmt.Errorf("missing value for '%v' at %d", tok.text, start)
+		}
+		toks = append(toks, tok)
+	}
+	return toks, nil
+}
+
+type filterParser struct {
+	toks []filterToken
+	pos  int
+}
+
+func (p *filterParser) keyword(k string) bool {
+	if p.pos >= len(p.toks) {
+		return false
+	}
+	t := p.toks[p.pos]
+	return t.kind == filterTokWord && strings.EqualFold(t.text, k)
+}
+
+func (p *filterParser) parseOr() (filterExpr, error) {
+	l, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		p.pos++
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l = filterOr{l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *filterParser) parseAnd() (filterExpr, error) {
+	l, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.pos < len(p.toks) {
+		if p.keyword("and") {
+			p.pos++
+		} else if p.keyword("or") || p.toks[p.pos].kind == filterTokRParen {
+			break
+		}
+		r, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l = filterAnd{l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *filterParser) parseUnary() (filterExpr, error) {
+	if p.pos >= len(p.toks) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	if p.keyword("not") {
+		p.pos++
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return filterNot{e: e}, nil
+	}
+
+	t := p.toks[p.pos]
+	p.pos++
+	switch t.kind {
+	case filterTokLParen:
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.toks) || p.toks[p.pos].kind != filterTokRParen {
+			return nil, fmt.Errorf("missing ')' for '(' at %d", t.pos)
+		}
+		p.pos++
+		return e, nil
+	case filterTokRParen:
+		return nil, fmt.Errorf("unexpected ')' at %d", t.pos)
+	case filterTokWord:
+		if strings.EqualFold(t.text, "and") || strings.EqualFold(t.text, "or") {
+			return nil, fmt.Errorf("unexpected '%v' at %d", t.text, t.pos)
+		}
+		if strings.ContainsAny(t.value, "*?") {
+			return filterPattern{field: FilterFieldLabel, re: GlobRegexp(t.value)}, nil
+		}
+		return filterContains{value: t.value}, nil
+	}
+
+	switch t.op {
+	case ":":
+		return filterPattern{field: t.field, re: GlobRegexp(t.value)}, nil
+	case "~":
+		re, err := regexp.Compile(t.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression at %d, %w", t.pos, err)
+		}
+		return filterPattern{field: t.field, re: re}, nil
+	}
+	return filterCompare{field: t.field, op: t.op, value: t.value}, nil
+}
+
+// Convert glob pattern to anchored regular expression, only '*' and '?' are supported, '*' also matches line breaks.
+func GlobRegexp(pat string) *regexp.Regexp {
+	b := strings.Builder{}
+	b.WriteString("(?s)^")
+	for _, r := range pat {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
@@ -0,0 +1,76 @@
+package graph
+
+import (
+	"testing"
+)
+
+func TestParseFilter(t *testing.T) {
+	nodes := []Node{}
+	nodes = append(nodes, Node{Id: 1, Label: "app", Attrs: map[string]string{"group": "com.curtisnewbie"}})
+	nodes = append(nodes, Node{Id: 2, Label: "jackson-core", Attrs: map[string]string{"group": "com.fasterxml.jackson.core", "scope": "compile"}})
+	nodes = append(nodes, Node{Id: 3, Label: "jackson-databind", Attrs: map[string]string{"group": "com.fasterxml.jackson.core", "scope": "test"}})
+	nodes = append(nodes, Node{Id: 4, Label: "junit", Attrs: map[string]string{"group": "junit", "scope": "test"}})
+
+	edges := []DEdge{}
+	edges = append(edges, DEdge{FromId: 1, ToId: 3})
+	edges = append(edges, DEdge{FromId: 1, ToId: 4})
+	edges = append(edges, DEdge{FromId: 3, ToId: 2})
+
+	g, err := NewDGraph("mygraph", nodes, edges)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		expr string
+		ids  []int
+	}{
+		{"jackson", []int{2, 3}},
+		{"group:com.fasterxml* and not scope:test", []int{2}},
+		{"group:com.fasterxml* not scope:test", []int{2}},
+		{"scope:test or label:app", []int{1, 3, 4}},
+		{"not (scope:test or label:app)", []int{2}},
+		{"label~databind$", []int{3}},
+		{"depth>=2", []int{2}},
+		{"depth=1 and outdeg=0", []int{4}},
+		{"indeg>0 and label:\"jackson-*\"", []int{2, 3}},
+		{"jack*core", []int{2}},
+		{"version:*", []int{}},
+	}
+	for _, c := range cases {
+		f, err := ParseFilter(c.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := g.FindNode(f.Predicate(g))
+		ids := []int{}
+		for _, n := range found {
+			ids = append(ids, n.Id)
+		}
+		if len(ids) != len(c.ids) {
+			t.Fatalf("'%v' should find %v, found %v", c.expr, c.ids, ids)
+		}
+		for i := range ids {
+			if ids[i] != c.ids[i] {
+				t.Fatalf("'%v' should find %v, found %v", c.expr, c.ids, ids)
+			}
+		}
+	}
+
+	for _, expr := range []string{"", "(scope:test", "scope:test)", "and scope:test", "label~\"(\"", "depth<", "not", "label:\"abc"} {
+		if _, err := ParseFilter(expr); err == nil {
+			t.Fatalf("'%v' should be invalid", expr)
+		} else {
+			t.Logf("%v", err)
+		}
+	}
+
+	f, err := ParseFilter("jackson-core")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.TreeShake(f.Predicate(g))
+	if g.NodeCount() != 3 {
+		t.Fatalf("should keep 3 nodes, kept %v", g.NodeCount())
+	}
+}
@@ -0,0 +1,167 @@
+package graph
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+const (
+	flowNoteColor = "#999999"
+	flowBarColor  = "#333333"
+)
+
+// Flowchart built on top of KNodeGraphBuilder.
+//
+// e.g.,
+//
+//	f := NewFlowchart()
+//	check := f.Start("Order Placed").Then("Check Stock")
+//	inStock := check.Decision("In Stock?")
+//	inStock.No("Restock").LoopTo(check, "retry")
+//	par := inStock.Yes("Confirm Order").Note("email is sent to customer").Fork("Charge Payment", "Reserve Courier")
+//	f.Join(par...).Then("Ship").End("Done")
+//	g, err := f.Build("order workflow")
+type Flowchart struct {
+	b   KNodeGraphBuilder
+	seq atomic.Int64
+}
+
+// Step in Flowchart.
+type Step struct {
+	f   *Flowchart
+	key string
+}
+
+// Decision step in Flowchart, branches are connected to the decision with labelled edges.
+type Decision struct {
+	*Step
+}
+
+func NewFlowchart() *Flowchart {
+	return &Flowchart{b: NewKNodeGraphBuilder()}
+}
+
+func (f *Flowchart) add(label string, shape string) *Step {
+	k := fmt.Sprintf("flow-%d", f.seq.Add(1))
+	f.b.Add(k, Node{Label: label, Shape: shape})
+	return &Step{f: f, key: k}
+}
+
+func (f *Flowchart) addStyled(n Node) *Step {
+	k := fmt.Sprintf("flow-%d", f.seq.Add(1))
+	f.b.Add(k, n)
+	return &Step{f: f, key: k}
+}
+
+// Add a start terminal.
+func (f *Flowchart) Start(label string) *Step {
+	return f.add(label, ShapeOval)
+}
+
+// Add a step that is not connected to any other step yet, e.g., a step that is only reached by loops.
+func (f *Flowchart) Step(label string) *Step {
+	return f.add(label, ShapeBox)
+}
+
+// Join the parallel steps, the returned step is connected from each of the given steps.
+func (f *Flowchart) Join(steps ...*Step) *Step {
+	j := f.addStyled(Node{Shape: ShapePoint, Color: flowBarColor, FillColor: flowBarColor})
+	for _, s := range steps {
+		s.To(j)
+	}
+	return j
+}
+
+// Build DGraph of the flowchart.
+func (f *Flowchart) Build(title string) (*DGraph, error) {
+	g, err := f.b.BuildDGraph(title)
+	if err != nil {
+		return nil, err
+	}
+	g.DisplayId = false
+	return g, nil
+}
+
+// Add a step connected from this step.
+func (s *Step) Then(label string) *Step {
+	n := s.f.add(label, ShapeBox)
+	s.f.b.Connect(s.key, n.key)
+	return n
+}
+
+// Add a decision connected from this step.
+func (s *Step) Decision(question string) *Decision {
+	n := s.f.add(question, ShapeDiamond)
+	s.f.b.Connect(s.key, n.key)
+	return &Decision{Step: n}
+}
+
+// Add an end terminal connected from this step.
+func (s *Step) End(label string) *Step {
+	n := s.f.add(label, ShapeOval)
+	s.f.b.Connect(s.key, n.key)
+	return n
+}
+
+// Connect this step to an existing step, e.g., to merge branches, the target step is returned.
+func (s *Step) To(target *Step) *Step {
+	s.f.b.Connect(s.key, target.key)
+	return target
+}
+
+// Loop back to an earlier step using the edge label, this step is returned.
+func (s *Step) LoopTo(target *Step, label string) *Step {
+	s.f.b.SConnect(s.key, target.key, label)
+	return s
+}
+
+// Fork parallel steps from this step, the returned steps are in the same order as the labels.
+func (s *Step) Fork(labels ...string) []*Step {
+	fork := s.f.addStyled(Node{Shape: ShapePoint, Color: flowBarColor, FillColor: flowBarColor})
+	s.f.b.Connect(s.key, fork.key)
+	steps := make([]*Step, 0, len(labels))
+	for _, l := range labels {
+		steps = append(steps, fork.Then(l))
+	}
+	return steps
+}
+
+// Attach a note to this step, this step is returned.
+func (s *Step) Note(text string) *Step {
+	n := s.f.add(text, ShapeNote)
+	s.f.b.ConnectEdge(s.key, n.key, DEdge{Style: "dashed", Color: flowNoteColor})
+	return s
+}
+
+// Add a step connected from the decision with the 'yes' label.
+func (d *Decision) Yes(label string) *Step {
+	return d.Branch("yes", label)
+}
+
+// Add a step connected from the decision with the 'no' label.
+func (d *Decision) No(label string) *Step {
+	return d.Branch("no", label)
+}
+
+// Add a step connected from the decision with the given edge label.
+func (d *Decision) Branch(edgeLabel string, label string) *Step {
+	n := d.f.add(label, ShapeBox)
+	d.f.b.SConnect(d.key, n.key, edgeLabel)
+	return n
+}
+
+// Connect the decision to an existing step with the 'yes' label, the target step is returned.
+func (d *Decision) YesTo(target *Step) *Step {
+	return d.BranchTo(target, "yes")
+}
+
+// Connect the decision to an existing step with the 'no' label, the target step is returned.
+func (d *Decision) NoTo(target *Step) *Step {
+	return d.BranchTo(target, "no")
+}
+
+// Connect the decision to an existing step with the given edge label, the target step is returned.
+func (d *Decision) BranchTo(target *Step, edgeLabel string) *Step {
+	d.f.b.SConnect(d.key, target.key, edgeLabel)
+	return target
+}
@@ -0,0 +1,65 @@
+package graph
+
+import (
+	"testing"
+)
+
+func TestFlowchart(t *testing.T) {
+	f := NewFlowchart()
+	check := f.Start("Order Placed").Then("Check Stock")
+	inStock := check.Decision("In Stock?")
+	inStock.No("Restock").LoopTo(check, "retry")
+	par := inStock.Yes("Confirm Order").Note("email is sent to customer").Fork("Charge Payment", "Reserve Courier")
+	f.Join(par...).Then("Ship").End("Done")
+
+	g, err := f.Build("order workflow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := g.SDraw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("s: %v", s)
+
+	shapes := map[string]string{}
+	ids := map[string]int{}
+	for _, n := range g.Nodes() {
+		shapes[n.Label] = n.Shape
+		ids[n.Label] = n.Id
+	}
+	for label, shape := range map[string]string{
+		"Order Placed":              ShapeOval,
+		"Done":                      ShapeOval,
+		"In Stock?":                 ShapeDiamond,
+		"Check Stock":               ShapeBox,
+		"email is sent to customer": ShapeNote,
+	} {
+		if shapes[label] != shape {
+			t.Fatalf("%v should be %v, but is %v", label, shape, shapes[label])
+		}
+	}
+
+	labels := map[[2]int]string{}
+	for _, ed := range g.Edges() {
+		labels[[2]int{ed.FromId, ed.ToId}] = ed.Label
+	}
+	for _, c := range []struct {
+		from, to, label string
+	}{
+		{"In Stock?", "Confirm Order", "yes"},
+		{"In Stock?", "Restock", "no"},
+		{"Restock", "Check Stock", "retry"},
+	} {
+		if l, ok := labels[[2]int{ids[c.from], ids[c.to]}]; !ok || l != c.label {
+			t.Fatalf("%v -> %v should be labelled %v", c.from, c.to, c.label)
+		}
+	}
+
+	if !g.Connected(ids["Order Placed"], ids["Done"]) {
+		t.Fatal("Order Placed should reach Done")
+	}
+	if !g.Connected(ids["Charge Payment"], ids["Ship"]) || !g.Connected(ids["Reserve Courier"], ids["Ship"]) {
+		t.Fatal("parallel steps should join before Ship")
+	}
+}
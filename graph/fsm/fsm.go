@@ -0,0 +1,184 @@
+package fsm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/curtisnewbie/grapher/graph"
+)
+
+const (
+	unreachableColor     = "#a6a6a6"
+	unreachableFillColor = "#eeeeee"
+	deadEndColor         = "#d9480f"
+	deadEndFillColor     = "#ffd8a8"
+)
+
+// Transition from one state to another.
+type Transition struct {
+	From   string
+	To     string
+	Event  string
+	Guard  string // optional
+	Action string // optional
+}
+
+// Label of the transition, e.g., 'PAY [amount > 0] / charge'.
+func (t Transition) Label() string {
+	l := t.Event
+	if t.Guard != "" {
+		l += " [" + t.Guard + "]"
+	}
+	if t.Action != "" {
+		l += " / " + t.Action
+	}
+	return strings.TrimSpace(l)
+}
+
+// State machine.
+type Machine struct {
+	Initial     string
+	Finals      []string
+	States      []string // states referenced by transitions are included automatically
+	Transitions []Transition
+}
+
+// Result of Machine.Analyze.
+type Analysis struct {
+	Unreachable []string // states that can't be reached from the initial state
+	DeadEnds    []string // non-final states that can't reach any final state, or have no outgoing transition if there is no final state
+}
+
+func (m *Machine) states() []string {
+	seen := map[string]struct{}{}
+	states := []string{}
+	add := func(s string) {
+		if _, ok := seen[s]; ok || s == "" {
+			return
+		}
+		seen[s] = struct{}{}
+		states = append(states, s)
+	}
+	add(m.Initial)
+	for _, s := range m.States {
+		add(s)
+	}
+	for _, t := range m.Transitions {
+		add(t.From)
+		add(t.To)
+	}
+	for _, s := range m.Finals {
+		add(s)
+	}
+	return states
+}
+
+func (m *Machine) isFinal(s string) bool {
+	for _, f := range m.Finals {
+		if f == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Machine) build(title string) (*graph.DGraph, *graph.KNodeGraphBuilder, error) {
+	if m.Initial == "" {
+		return nil, nil, fmt.Errorf("initial state is missing")
+	}
+
+	b := graph.NewKNodeGraphBuilder()
+	for _, s := range m.states() {
+		shape := graph.ShapeBox
+		if s == m.Initial {
+			shape = graph.ShapeCircle
+		} else if m.isFinal(s) {
+			shape = graph.ShapeDoubleCircle
+		}
+		b.SAddShape(s, s, shape)
+	}
+
+	// transitions between the same states are merged into one edge
+	labels := map[[2]string][]string{}
+	for _, t := range m.Transitions {
+		k := [2]string{t.From, t.To}
+		labels[k] = append(labels[k], t.Label())
+		b.SConnect(t.From, t.To, strings.Join(labels[k], "\n"))
+	}
+
+	g, err := b.BuildDGraph(title)
+	if err != nil {
+		return nil, nil, err
+	}
+	g.DisplayId = false
+	return g, &b, nil
+}
+
+// Find unreachable and dead-end states.
+func (m *Machine) Analyze() (Analysis, error) {
+	g, b, err := m.build("")
+	if err != nil {
+		return Analysis{}, err
+	}
+	return m.analyze(g, b), nil
+}
+
+func (m *Machine) analyze(g *graph.DGraph, b *graph.KNodeGraphBuilder) Analysis {
+	a := Analysis{Unreachable: []string{}, DeadEnds: []string{}}
+	initial, _ := b.Find(m.Initial)
+	for _, s := range m.states() {
+		n, _ := b.Find(s)
+		if s != m.Initial && !g.Connected(initial.Id, n.Id) {
+			a.Unreachable = append(a.Unreachable, s)
+		}
+		if m.isFinal(s) {
+			continue
+		}
+		if len(m.Finals) < 1 {
+			if len(g.OutEdges(n.Id)) < 1 {
+				a.DeadEnds = append(a.DeadEnds, s)
+			}
+			continue
+		}
+		reachFinal := false
+		for _, f := range m.Finals {
+			fn, _ := b.Find(f)
+			if g.Connected(n.Id, fn.Id) {
+				reachFinal = true
+				break
+			}
+		}
+		if !reachFinal {
+			a.DeadEnds = append(a.DeadEnds, s)
+		}
+	}
+	return a
+}
+
+// Build styled DGraph of the state machine.
+//
+// Initial state is drawn as a circle, final states are drawn as double circles, unreachable states are greyed out and
+// dead-end states are highlighted.
+func (m *Machine) Build(title string) (*graph.DGraph, error) {
+	g, b, err := m.build(title)
+	if err != nil {
+		return nil, err
+	}
+	a := m.analyze(g, b)
+	for _, s := range a.DeadEnds {
+		n, _ := b.Find(s)
+		g.UpdateNode(n.Id, func(n *graph.Node) {
+			n.Color = deadEndColor
+			n.FillColor = deadEndFillColor
+		})
+	}
+	for _, s := range a.Unreachable {
+		n, _ := b.Find(s)
+		g.UpdateNode(n.Id, func(n *graph.Node) {
+			n.Color = unreachableColor
+			n.FillColor = unreachableFillColor
+			n.FontColor = unreachableColor
+		})
+	}
+	return g, nil
+}
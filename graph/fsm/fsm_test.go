@@ -0,0 +1,70 @@
+package fsm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/curtisnewbie/grapher/graph"
+)
+
+func TestMachine(t *testing.T) {
+	m := Machine{
+		Initial: "CREATED",
+		Finals:  []string{"COMPLETED", "CANCELLED"},
+		States:  []string{"ARCHIVED"},
+		Transitions: []Transition{
+			{From: "CREATED", To: "PAID", Event: "PAY", Guard: "amount > 0", Action: "charge"},
+			{From: "CREATED", To: "CANCELLED", Event: "CANCEL"},
+			{From: "PAID", To: "SHIPPED", Event: "SHIP"},
+			{From: "PAID", To: "REFUNDING", Event: "REFUND"},
+			{From: "SHIPPED", To: "COMPLETED", Event: "RECEIVE"},
+			{From: "SHIPPED", To: "COMPLETED", Event: "TIMEOUT", Action: "autoConfirm"},
+		},
+	}
+
+	a, err := m.Analyze()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(a.Unreachable, []string{"ARCHIVED"}) {
+		t.Fatalf("unexpected unreachable states, %v", a.Unreachable)
+	}
+	if !reflect.DeepEqual(a.DeadEnds, []string{"ARCHIVED", "REFUNDING"}) {
+		t.Fatalf("unexpected dead-end states, %v", a.DeadEnds)
+	}
+
+	g, err := m.Build("order lifecycle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := g.SDraw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("s: %v", s)
+
+	nodes := map[string]graph.Node{}
+	for _, n := range g.Nodes() {
+		nodes[n.Label] = n
+	}
+	if nodes["CREATED"].Shape != graph.ShapeCircle || nodes["COMPLETED"].Shape != graph.ShapeDoubleCircle {
+		t.Fatal("initial and final states should have distinct shapes")
+	}
+	if nodes["REFUNDING"].FillColor != deadEndFillColor || nodes["ARCHIVED"].FontColor != unreachableColor {
+		t.Fatal("dead-end and unreachable states should be highlighted")
+	}
+
+	labels := map[string]bool{}
+	for _, ed := range g.Edges() {
+		labels[ed.Label] = true
+	}
+	for _, l := range []string{"PAY [amount > 0] / charge", "RECEIVE\nTIMEOUT / autoConfirm"} {
+		if !labels[l] {
+			t.Fatalf("missing transition label %q, %v", l, labels)
+		}
+	}
+
+	if _, err := (&Machine{}).Build("empty"); err == nil {
+		t.Fatal("should fail without initial state")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
 
 	"github.com/curtisnewbie/grapher/log"
 	"github.com/curtisnewbie/grapher/sys"
@@ -31,23 +32,60 @@ const (
 	ShapeCircle  = "circle"
 	ShapeNote    = "note"
 	ShapeDiamond = "diamond"
+	ShapeOval    = "oval"
+
+	ShapeDoubleCircle = "doublecircle"
+
+	defaultNodeColor     = "#b20400"
+	defaultNodeFillColor = "#edd6d5"
+	defaultEdgeColor     = "#b2a999"
+
+	clusterColor = "#999999"
+
+	highlightNodeColor     = "#b20400"
+	highlightNodeFillColor = "#f5a9a6"
+	highlightEdgeColor     = "#b20400"
+	highlightPenWidth      = "2"
+	dimColor               = "#d9d9d9"
+	dimFillColor           = "#f8f8f8"
+	dimFontColor           = "#a6a6a6"
 )
 
 type DEdge struct {
-	FromId  int
-	ToId    int
-	Label   string
-	Tooltip string
+	FromId   int
+	ToId     int
+	Label    string
+	Tooltip  string
+	Color    string // edge color, by default it's #b2a999.
+	Style    string // edge style, e.g., dashed, dotted, bold, by default it's empty.
+	PenWidth string // edge pen width, by default it's empty.
+
+	// Arbitrary attributes of the edge, these are not rendered.
+	Attrs map[string]string
 }
 
 type Node struct {
-	Id      int
-	Label   string
-	Tooltip string
-	Shape   string
+	Id        int
+	Label     string
+	Tooltip   string
+	Shape     string
+	Color     string // border color, by default it's #b20400.
+	FillColor string // fill color, by default it's #edd6d5.
+	FontColor string // font color, by default it's empty.
+	Cluster   string // name of the cluster that the node is drawn in, by default it's empty.
+
+	// Arbitrary attributes of the node, e.g., group, version, etc, these are not rendered but can be queried using Filter.
+	Attrs map[string]string
 }
 
+// Directed graph.
+//
+// Methods of DGraph are thread-safe, nodes and edges are protected by a read/write lock. Predicates and update
+// functions passed to DGraph are called while the lock is held, they should not call methods of the same graph.
+//
+// The exported fields (e.g., Layout, Dpi) are not protected, they should be configured before the graph is shared.
 type DGraph struct {
+	mu    sync.RWMutex
 	title string
 	nodes []Node
 	edges []DEdge
@@ -102,15 +140,93 @@ func (d *DGraph) build() error {
 }
 
 func (d *DGraph) FindNodeLike(label string) []Node {
+	return d.FindNode(func(n Node) bool { return strings.Contains(n.Label, label) })
+}
+
+// Find nodes that match the given predicate, e.g., a predicate compiled by Filter.
+func (d *DGraph) FindNode(f func(n Node) bool) []Node {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 	res := []Node{}
 	for i, n := range d.nodes {
-		if strings.Contains(n.Label, label) {
+		if f(n) {
 			res = append(res, d.nodes[i])
 		}
 	}
 	return res
 }
 
+// Find node by id.
+func (d *DGraph) Node(id int) (Node, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.node(id)
+}
+
+// Copy of all nodes in the graph.
+func (d *DGraph) Nodes() []Node {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return append([]Node{}, d.nodes...)
+}
+
+// Copy of all edges in the graph.
+func (d *DGraph) Edges() []DEdge {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return append([]DEdge{}, d.edges...)
+}
+
+// Copy of edges starting from the given node.
+func (d *DGraph) OutEdges(id int) []DEdge {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return append([]DEdge{}, d.nodeEdges[id]...)
+}
+
+// Create an independent snapshot of the graph, the snapshot can be rendered or queried while the original graph
+// is being updated.
+func (d *DGraph) Clone() *DGraph {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	nodes := make([]Node, 0, len(d.nodes))
+	for _, n := range d.nodes {
+		n.Attrs = copyAttrs(n.Attrs)
+		nodes = append(nodes, n)
+	}
+	edges := make([]DEdge, 0, len(d.edges))
+	for _, ed := range d.edges {
+		ed.Attrs = copyAttrs(ed.Attrs)
+		edges = append(edges, ed)
+	}
+	cp := new(DGraph)
+	cp.title = d.title
+	cp.nodes = nodes
+	cp.edges = edges
+	cp.Layout = d.Layout
+	cp.DisplayId = d.DisplayId
+	cp.RankSep = d.RankSep
+	cp.NodeSep = d.NodeSep
+	cp.Ratio = d.Ratio
+	cp.Pad = d.Pad
+	cp.Dpi = d.Dpi
+	cp.Debug = d.Debug
+	_ = cp.build() // nodes and edges are already validated
+	return cp
+}
+
+func copyAttrs(attrs map[string]string) map[string]string {
+	if attrs == nil {
+		return nil
+	}
+	cp := make(map[string]string, len(attrs))
+	for k, v := range attrs {
+		cp[k] = v
+	}
+	return cp
+}
+
 func (d *DGraph) node(id int) (Node, bool) {
 	v, ok := d.nodeMap[id]
 	if !ok {
@@ -120,6 +236,8 @@ func (d *DGraph) node(id int) (Node, bool) {
 }
 
 func (d *DGraph) TreeShake(f func(n Node) bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 
 	for i := len(d.nodes) - 1; i >= 0; i-- {
 		met := map[int]struct{}{}
@@ -193,7 +311,136 @@ func (d *DGraph) treeShakeAt(met map[int]struct{}, f func(n Node) bool, id int)
 	return false
 }
 
+// Highlight nodes that match the given predicate and the edges on paths leading to them.
+//
+// Unlike TreeShake, nothing is removed from the graph, nodes and edges that are not on any path to the
+// matched nodes are dimmed instead.
+func (d *DGraph) Highlight(f func(n Node) bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	matched := map[int]struct{}{}
+	queue := []int{}
+	for _, n := range d.nodes {
+		if f(n) {
+			matched[n.Id] = struct{}{}
+			queue = append(queue, n.Id)
+		}
+	}
+
+	// walk the edges backwards to find the nodes that can reach any of the matched nodes
+	parents := map[int][]int{}
+	for _, ed := range d.edges {
+		parents[ed.ToId] = append(parents[ed.ToId], ed.FromId)
+	}
+	onPath := map[int]struct{}{}
+	for len(queue) > 0 {
+		pop := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		for _, p := range parents[pop] {
+			if _, ok := onPath[p]; ok {
+				continue
+			}
+			onPath[p] = struct{}{}
+			queue = append(queue, p)
+		}
+	}
+
+	// nodes and edges are updated in place, then nodeMap and nodeEdges are synced in one pass
+	for i := range d.nodes {
+		n := &d.nodes[i]
+		_, isMatched := matched[n.Id]
+		_, isOnPath := onPath[n.Id]
+		if d.Debug {
+			log.Debugf("highlighting node: %#v, matched: %v, on path: %v", *n, isMatched, isOnPath)
+		}
+		if isMatched {
+			n.Color = highlightNodeColor
+			n.FillColor = highlightNodeFillColor
+		} else if !isOnPath {
+			n.Color = dimColor
+			n.FillColor = dimFillColor
+			n.FontColor = dimFontColor
+		}
+		d.nodeMap[n.Id] = *n
+	}
+
+	d.nodeEdges = map[int][]DEdge{}
+	for i := range d.edges {
+		e := &d.edges[i]
+		_, toMatched := matched[e.ToId]
+		_, toOnPath := onPath[e.ToId]
+		if toMatched || toOnPath {
+			e.Color = highlightEdgeColor
+			e.PenWidth = highlightPenWidth
+		} else {
+			e.Color = dimColor
+		}
+		d.nodeEdges[e.FromId] = append(d.nodeEdges[e.FromId], *e)
+	}
+}
+
+// Update node identified by the given id, return false if the node is not found.
+//
+// Node.Id is always preserved.
+func (d *DGraph) UpdateNode(id int, f func(n *Node)) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d._updateNode(id, f)
+}
+
+func (d *DGraph) _updateNode(id int, f func(n *Node)) bool {
+	n, ok := d.nodeMap[id]
+	if !ok {
+		return false
+	}
+	f(&n)
+	n.Id = id
+	d.nodeMap[id] = n
+	for i := range d.nodes {
+		if d.nodes[i].Id == id {
+			d.nodes[i] = n
+			break
+		}
+	}
+	return true
+}
+
+// Update the directed edge connecting the two nodes, return false if the edge is not found.
+//
+// DEdge.FromId and DEdge.ToId are always preserved.
+func (d *DGraph) UpdateEdge(fromId int, toId int, f func(e *DEdge)) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d._updateEdge(fromId, toId, f)
+}
+
+func (d *DGraph) _updateEdge(fromId int, toId int, f func(e *DEdge)) bool {
+	eds := d.nodeEdges[fromId]
+	for i := range eds {
+		if eds[i].ToId != toId {
+			continue
+		}
+		e := eds[i]
+		f(&e)
+		e.FromId = fromId
+		e.ToId = toId
+		eds[i] = e
+		for j := range d.edges {
+			if d.edges[j].FromId == fromId && d.edges[j].ToId == toId {
+				d.edges[j] = e
+				break
+			}
+		}
+		return true
+	}
+	return false
+}
+
 func (d *DGraph) Subgraph(rootId int) (*DGraph, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
 	var root Node
 	var found bool = false
 	for _, n := range d.nodes {
@@ -237,6 +484,9 @@ func (d *DGraph) Subgraph(rootId int) (*DGraph, error) {
 }
 
 func (d *DGraph) Connected(rootId int, targetId int) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
 	root, ok := d.nodeMap[rootId]
 	if !ok {
 		return false
@@ -279,6 +529,9 @@ func (d *DGraph) Connect(fromId int, toId int) bool {
 //
 // When false is returned, these is already a directed edge connecting the two nodes.
 func (d *DGraph) AddEdge(edge DEdge) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	fromId := edge.FromId
 	toId := edge.ToId
 
@@ -288,7 +541,6 @@ func (d *DGraph) AddEdge(edge DEdge) bool {
 	}
 	eds, ok := d.nodeEdges[fromId]
 	if !ok {
-		edge := DEdge{FromId: fromId, ToId: toId}
 		d.edges = append(d.edges, edge)
 		d.nodeEdges[fromId] = []DEdge{edge}
 		return true
@@ -307,6 +559,8 @@ func (d *DGraph) AddEdge(edge DEdge) bool {
 
 // Add node to graph, return false if the node.Id exist.
 func (d *DGraph) AddNode(n Node) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	_, ok := d.nodeMap[n.Id]
 	if ok {
 		return false
@@ -327,27 +581,48 @@ func (d *DGraph) SDraw() (string, error) {
 }
 
 func (d *DGraph) Draw(w io.Writer) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
 	if err := d.writeGraphAttr(w); err != nil {
 		return fmt.Errorf("failed to write graph attributes, %w", err)
 	}
 
 	buf := bytes.Buffer{}
+	clusters := []string{}
+	clustered := map[string][]Node{}
 	for _, n := range d.nodes {
-		label := n.Label
-		if d.DisplayId {
-			label = fmt.Sprintf("%d. %s", n.Id, n.Label)
+		if n.Cluster != "" {
+			if _, ok := clustered[n.Cluster]; !ok {
+				clusters = append(clusters, n.Cluster)
+			}
+			clustered[n.Cluster] = append(clustered[n.Cluster], n)
+			continue
 		}
-		shape := n.Shape
-		if shape == "" {
-			shape = ShapeBox
+		d.writeNode(&buf, n)
+	}
+	for i, c := range clusters {
+		buf.WriteString(fmt.Sprintf("subgraph \"cluster_%d\" {\nlabel=\"%s\" fontsize=10 style=\"rounded,dashed\" color=\"%s\"\n", i, c, clusterColor))
+		for _, n := range clustered[c] {
+			d.writeNode(&buf, n)
 		}
-		buf.WriteString(fmt.Sprintf("N%v [label=\"%v\" id=\"node%v\" fontsize=8 shape=%s tooltip=\"%v\" color=\"#b20400\" fillcolor=\"#edd6d5\"]\n",
-			n.Id, label, n.Id, shape, n.Tooltip))
+		buf.WriteString("}\n")
 	}
 
 	for _, ed := range d.edges {
-		buf.WriteString(fmt.Sprintf("N%v -> N%v [label=\" %s\" labelfloat=false fontsize=6 weight=1 color=\"#b2a999\" tooltip=\"%s\"]\n",
-			ed.FromId, ed.ToId, ed.Label, ed.Tooltip))
+		color := ed.Color
+		if color == "" {
+			color = defaultEdgeColor
+		}
+		extra := ""
+		if ed.Style != "" {
+			extra += fmt.Sprintf(" style=\"%s\"", ed.Style)
+		}
+		if ed.PenWidth != "" {
+			extra += fmt.Sprintf(" penwidth=%s", ed.PenWidth)
+		}
+		buf.WriteString(fmt.Sprintf("N%v -> N%v [label=\" %s\" labelfloat=false fontsize=6 weight=1 color=\"%s\" tooltip=\"%s\"%s]\n",
+			ed.FromId, ed.ToId, ed.Label, color, ed.Tooltip, extra))
 	}
 	buf.WriteString("}\n")
 	_, err := w.Write(buf.Bytes())
@@ -357,6 +632,31 @@ func (d *DGraph) Draw(w io.Writer) error {
 	return nil
 }
 
+func (d *DGraph) writeNode(buf *bytes.Buffer, n Node) {
+	label := n.Label
+	if d.DisplayId {
+		label = fmt.Sprintf("%d. %s", n.Id, n.Label)
+	}
+	shape := n.Shape
+	if shape == "" {
+		shape = ShapeBox
+	}
+	color := n.Color
+	if color == "" {
+		color = defaultNodeColor
+	}
+	fillColor := n.FillColor
+	if fillColor == "" {
+		fillColor = defaultNodeFillColor
+	}
+	extra := ""
+	if n.FontColor != "" {
+		extra += fmt.Sprintf(" fontcolor=\"%s\"", n.FontColor)
+	}
+	buf.WriteString(fmt.Sprintf("N%v [label=\"%v\" id=\"node%v\" fontsize=8 shape=%s tooltip=\"%v\" color=\"%s\" fillcolor=\"%s\"%s]\n",
+		n.Id, label, n.Id, shape, n.Tooltip, color, fillColor, extra))
+}
+
 func (d *DGraph) writeGraphAttr(w io.Writer) error {
 	b := bytes.Buffer{}
 	b.WriteString(fmt.Sprintf("digraph \"[%v]\" {\n", d.title))
@@ -388,11 +688,20 @@ func (d *DGraph) writeGraphAttr(w io.Writer) error {
 	return err
 }
 
+// Title of the graph.
+func (d *DGraph) Title() string {
+	return d.title
+}
+
 func (d *DGraph) NodeCount() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 	return len(d.nodes)
 }
 
 func (d *DGraph) EdgeCount() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 	return len(d.edges)
 }
 
@@ -438,13 +747,17 @@ func DotGen(g *DGraph, p DotGenParam) (DotGenParam, error) {
 		tmpFile.Close()
 	}
 
-	if !p.DisableAutoScale && p.Format != "svg" && g.Dpi == "" {
-		if g.NodeCount() > 50 {
-			exp := int(g.NodeCount() / 50)
-			g.Dpi = cast.ToString(exp * 300)
-		} else {
-			g.Dpi = "300"
+	if !p.DisableAutoScale && p.Format != "svg" {
+		g.mu.Lock()
+		if g.Dpi == "" {
+			if len(g.nodes) > 50 {
+				exp := int(len(g.nodes) / 50)
+				g.Dpi = cast.ToString(exp * 300)
+			} else {
+				g.Dpi = "300"
+			}
 		}
+		g.mu.Unlock()
 	}
 
 	s, err := g.SDraw()
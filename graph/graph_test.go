@@ -1,7 +1,9 @@
 package graph
 
 import (
+	"fmt"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/curtisnewbie/grapher/sys"
@@ -115,3 +117,97 @@ func TestDGraphTreeShake(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestDGraphHighlight(t *testing.T) {
+	nodes := []Node{}
+	nodes = append(nodes, Node{Id: 1, Label: "mini-fstore"})
+	nodes = append(nodes, Node{Id: 2, Label: "vfm"})
+	nodes = append(nodes, Node{Id: 3, Label: "user-vault"})
+	nodes = append(nodes, Node{Id: 4, Label: "goauth"})
+	nodes = append(nodes, Node{Id: 5, Label: "banana"})
+
+	edges := []DEdge{}
+	edges = append(edges, DEdge{FromId: 2, ToId: 3})
+	edges = append(edges, DEdge{FromId: 1, ToId: 3})
+	edges = append(edges, DEdge{FromId: 3, ToId: 4})
+	edges = append(edges, DEdge{FromId: 2, ToId: 5})
+
+	g, err := NewDGraph("mygraph", nodes, edges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Highlight(func(n Node) bool { return n.Label == "goauth" })
+
+	if g.NodeCount() != 5 || g.EdgeCount() != 4 {
+		t.Fatalf("highlight should not remove anything, nodes: %v, edges: %v", g.NodeCount(), g.EdgeCount())
+	}
+	if n, _ := g.node(4); n.FillColor != highlightNodeFillColor {
+		t.Fatalf("goauth should be highlighted, %#v", n)
+	}
+	if n, _ := g.node(2); n.FontColor != "" {
+		t.Fatalf("vfm is on the path to goauth, %#v", n)
+	}
+	if n, _ := g.node(5); n.FontColor != dimFontColor {
+		t.Fatalf("banana should be dimmed, %#v", n)
+	}
+	for _, ed := range g.edges {
+		want := highlightEdgeColor
+		if ed.ToId == 5 {
+			want = dimColor
+		}
+		if ed.Color != want {
+			t.Fatalf("edge %v -> %v should be %v, %#v", ed.FromId, ed.ToId, want, ed)
+		}
+		for _, oe := range g.OutEdges(ed.FromId) {
+			if oe.ToId == ed.ToId && oe.Color != want {
+				t.Fatalf("out edges of %v should be synced, %#v", ed.FromId, oe)
+			}
+		}
+	}
+
+	s, err := g.SDraw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("s: %v", s)
+}
+
+func TestDGraphConcurrentAccess(t *testing.T) {
+	g, err := NewDGraph("mygraph", []Node{{Id: 0, Label: "root"}}, []DEdge{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	async := func(f func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f()
+		}()
+	}
+
+	for i := 1; i <= 50; i++ {
+		id := i
+		async(func() {
+			g.AddNode(Node{Id: id, Label: fmt.Sprintf("service-%d", id)})
+			g.Connect(0, id)
+		})
+		async(func() {
+			if _, err := g.SDraw(); err != nil {
+				t.Error(err)
+			}
+		})
+		async(func() {
+			snapshot := g.Clone()
+			snapshot.TreeShake(func(n Node) bool { return n.Id%2 == 0 })
+			g.Connected(0, id)
+		})
+	}
+	async(func() { g.Highlight(func(n Node) bool { return n.Id == 3 }) })
+	wg.Wait()
+
+	if g.NodeCount() != 51 {
+		t.Fatalf("should have 51 nodes, %v", g.NodeCount())
+	}
+}
@@ -0,0 +1,189 @@
+package mvn
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/curtisnewbie/grapher/graph"
+	"github.com/curtisnewbie/grapher/parser/mvn/version"
+)
+
+const (
+	maxConflictPaths = 20
+
+	conflictNodeFillColor = "#ffe3a3"
+)
+
+// Artifact (groupId:artifactId) that appears with multiple versions in MvnGraph.
+type Conflict struct {
+	GroupId    string            `json:"groupId"`
+	ArtifactId string            `json:"artifactId"`
+	Selected   []string          `json:"selected"` // versions selected by maven, may be more than one if modules select different versions
+	Highest    string            `json:"highest"`  // highest version present, versions are ordered as Maven's ComparableVersion
+	Versions   []ConflictVersion `json:"versions"`
+}
+
+// Version of the conflicting artifact.
+type ConflictVersion struct {
+	Version  string     `json:"version"`
+	Selected bool       `json:"selected"`
+	NodeIds  []int      `json:"-"`
+	Paths    [][]string `json:"paths"` // coordinates on each path from the root, at most 20 paths are included
+}
+
+// Find artifacts (groupId:artifactId) that appear with multiple versions, versions are sorted in the order they are
+// found.
+//
+// A version is selected if it's reached through any resolved edge, versions that are omitted for conflict only appear
+// in the output of mvn dependency:tree -Dverbose.
+func (g *MvnGraph) Conflicts() []Conflict {
+	type artifact struct {
+		conflict Conflict
+		versions map[string]int // version -> index in conflict.Versions
+	}
+	artifacts := map[string]*artifact{}
+	order := []string{}
+	for _, n := range g.Nodes() {
+		c, ok := g.coords[n.Id]
+		if !ok {
+			continue
+		}
+		a, ok := artifacts[c.GroupArtifact()]
+		if !ok {
+			a = &artifact{conflict: Conflict{GroupId: c.GroupId, ArtifactId: c.ArtifactId}, versions: map[string]int{}}
+			artifacts[c.GroupArtifact()] = a
+			order = append(order, c.GroupArtifact())
+		}
+		i, ok := a.versions[c.Version]
+		if !ok {
+			i = len(a.conflict.Versions)
+			a.versions[c.Version] = i
+			a.conflict.Versions = append(a.conflict.Versions, ConflictVersion{Version: c.Version})
+		}
+		a.conflict.Versions[i].NodeIds = append(a.conflict.Versions[i].NodeIds, n.Id)
+	}
+
+	parents := g.parentDependencies()
+
+	conflicts := []Conflict{}
+	for _, k := range order {
+		a := artifacts[k]
+		if len(a.conflict.Versions) < 2 {
+			continue
+		}
+		cf := a.conflict
+		cf.Selected = []string{}
+		for i := range cf.Versions {
+			v := &cf.Versions[i]
+			if i == 0 || version.Compare(v.Version, cf.Highest) > 0 {
+				cf.Highest = v.Version
+			}
+			for _, id := range v.NodeIds {
+				if g.IsModule(id) {
+					v.Selected = true
+				}
+				for _, d := range parents[id] {
+					if !d.Omitted() {
+						v.Selected = true
+					}
+				}
+				v.Paths = append(v.Paths, g.paths(parents, id, maxConflictPaths-len(v.Paths))...)
+			}
+			if v.Selected {
+				cf.Selected = append(cf.Selected, v.Version)
+			}
+		}
+		conflicts = append(conflicts, cf)
+	}
+	return conflicts
+}
+
+// Find paths from roots to the node, only resolved edges are followed except the last one, at most limit paths are
+// returned.
+func (g *MvnGraph) paths(parents map[int][]Dependency, id int, limit int) [][]string {
+	paths := [][]string{}
+	var walk func(id int, path []int, visited map[int]bool, last bool)
+	walk = func(id int, path []int, visited map[int]bool, last bool) {
+		if len(paths) >= limit || visited[id] {
+			return
+		}
+		path = append(path, id)
+		ps := parents[id]
+		found := false
+		visited[id] = true
+		for _, d := range ps {
+			if d.Omitted() && !last {
+				continue
+			}
+			found = true
+			walk(d.FromId, path, visited, false)
+		}
+		delete(visited, id)
+		if !found {
+			p := make([]string, 0, len(path))
+			for i := len(path) - 1; i >= 0; i-- {
+				p = append(p, g.coords[path[i]].String())
+			}
+			paths = append(paths, p)
+		}
+	}
+	walk(id, []int{}, map[int]bool{}, true)
+	return paths
+}
+
+// Format conflicts as human-readable text.
+func FormatConflicts(conflicts []Conflict) string {
+	b := strings.Builder{}
+	for i, c := range conflicts {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(fmt.Sprintf("%v:%v, selected: %v, highest: %v\n", c.GroupId, c.ArtifactId, strings.Join(c.Selected, ", "), c.Highest))
+		for _, v := range c.Versions {
+			mark := " "
+			if v.Selected {
+				mark = "*"
+			}
+			b.WriteString(fmt.Sprintf("  %v %v\n", mark, v.Version))
+			for _, p := range v.Paths {
+				b.WriteString("      " + strings.Join(p, " -> ") + "\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// Format conflicts as JSON.
+func FormatConflictsJSON(conflicts []Conflict) ([]byte, error) {
+	return json.MarshalIndent(conflicts, "", "  ")
+}
+
+// Highlight nodes of the conflicting artifacts and the paths leading to them, versions that are not selected are
+// filled with a different color.
+func (g *MvnGraph) HighlightConflicts(conflicts []Conflict) {
+	nodes := map[int]ConflictVersion{}
+	selected := map[int]string{}
+	for _, c := range conflicts {
+		for _, v := range c.Versions {
+			for _, id := range v.NodeIds {
+				nodes[id] = v
+				selected[id] = strings.Join(c.Selected, ", ")
+			}
+		}
+	}
+	g.Highlight(func(n graph.Node) bool {
+		_, ok := nodes[n.Id]
+		return ok
+	})
+	for id, v := range nodes {
+		if v.Selected {
+			continue
+		}
+		sel := selected[id]
+		g.UpdateNode(id, func(n *graph.Node) {
+			n.FillColor = conflictNodeFillColor
+			n.Tooltip = fmt.Sprintf("%v is not selected, selected: %v", v.Version, sel)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package mvn
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestConflicts(t *testing.T) {
+	ctn, err := os.ReadFile("../../testdata/verbose_mvn.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := ParseMvnGraph("dependency tree", string(ctn))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conflicts := g.Conflicts()
+	t.Logf("\n%v", FormatConflicts(conflicts))
+	if len(conflicts) != 1 {
+		t.Fatalf("should have 1 conflict, %#v", conflicts)
+	}
+	c := conflicts[0]
+	if c.GroupId != "com.fasterxml.jackson.core" || c.ArtifactId != "jackson-databind" {
+		t.Fatalf("unexpected conflict, %#v", c)
+	}
+	if len(c.Selected) != 1 || c.Selected[0] != "2.12.0" {
+		t.Fatalf("2.12.0 should be selected, %v", c.Selected)
+	}
+	if c.Highest != "2.12.0" {
+		t.Fatalf("2.12.0 should be the highest, %v", c.Highest)
+	}
+	if len(c.Versions) != 2 || c.Versions[1].Version != "2.11.2" || c.Versions[1].Selected {
+		t.Fatalf("2.11.2 should not be selected, %#v", c.Versions)
+	}
+	want := []string{
+		"com.curtisnewbie:order-app:jar:1.0.0",
+		"com.amazonaws:aws-java-sdk-core:jar:1.11.900:compile",
+		"com.fasterxml.jackson.core:jackson-databind:jar:2.11.2:compile",
+	}
+	if p := c.Versions[1].Paths; len(p) != 1 || strings.Join(p[0], ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected paths, %v", p)
+	}
+
+	b, err := FormatConflictsJSON(conflicts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed []Conflict
+	if err := json.Unmarshal(b, &parsed); err != nil || len(parsed) != 1 || parsed[0].Versions[1].Paths[0][1] != want[1] {
+		t.Fatalf("unexpected json, %v, %s", err, b)
+	}
+
+	g.HighlightConflicts(conflicts)
+	n, _ := g.Node(c.Versions[1].NodeIds[0])
+	if n.FillColor != conflictNodeFillColor {
+		t.Fatalf("2.11.2 should be filled with conflict color, %#v", n)
+	}
+}
@@ -0,0 +1,172 @@
+package mvn
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/curtisnewbie/grapher/parser/mvn/version"
+)
+
+const (
+	AttrGroup      = "group"
+	AttrArtifact   = "artifact"
+	AttrPackaging  = "packaging"
+	AttrClassifier = "classifier"
+	AttrVersion    = "version"
+	AttrScope      = "scope"
+	AttrOptional   = "optional"
+
+	ScopeCompile  = "compile"
+	ScopeTest     = "test"
+	ScopeProvided = "provided"
+	ScopeRuntime  = "runtime"
+	ScopeSystem   = "system"
+	ScopeImport   = "import"
+
+	optionalSuffix = "(optional)"
+)
+
+var scopes = []string{ScopeCompile, ScopeTest, ScopeProvided, ScopeRuntime, ScopeSystem, ScopeImport}
+
+// Maven coordinate of an artifact.
+type Coordinate struct {
+	GroupId    string `json:"groupId"`
+	ArtifactId string `json:"artifactId"`
+	Packaging  string `json:"packaging"`            // e.g., jar, pom, war.
+	Classifier string `json:"classifier,omitempty"` // optional, e.g., sources, tests.
+	Version    string `json:"version"`
+	Scope      string `json:"scope,omitempty"` // optional, e.g., compile, test, provided.
+	Optional   bool   `json:"optional,omitempty"`
+}
+
+// Parse coordinate in the format used by dependency:tree, i.e.,
+//
+//	groupId:artifactId:packaging[:classifier]:version[:scope] [(optional)]
+//
+// groupId:artifactId:version is also accepted.
+func ParseCoordinate(s string) (Coordinate, error) {
+	c := Coordinate{}
+	s = strings.TrimSpace(s)
+	if v, ok := strings.CutSuffix(s, optionalSuffix); ok {
+		s = strings.TrimSpace(v)
+		c.Optional = true
+	}
+
+	tkn := strings.Split(s, ":")
+	for _, t := range tkn {
+		if t == "" || strings.ContainsAny(t, " \t") {
+			return c, fmt.Errorf("invalid coordinate '%v'", s)
+		}
+	}
+	if n := len(tkn); n > 4 && isScope(tkn[n-1]) {
+		c.Scope = tkn[n-1]
+		tkn = tkn[:n-1]
+	}
+
+	switch len(tkn) {
+	case 3:
+		c.GroupId, c.ArtifactId, c.Version = tkn[0], tkn[1], tkn[2]
+	case 4:
+		c.GroupId, c.ArtifactId, c.Packaging, c.Version = tkn[0], tkn[1], tkn[2], tkn[3]
+	case 5:
+		c.GroupId, c.ArtifactId, c.Packaging, c.Classifier, c.Version = tkn[0], tkn[1], tkn[2], tkn[3], tkn[4]
+	default:
+		return c, fmt.Errorf("invalid coordinate '%v'", s)
+	}
+	return c, nil
+}
+
+func isScope(s string) bool {
+	for _, sc := range scopes {
+		if sc == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Coordinate without scope and optional flag, i.e., groupId:artifactId:packaging[:classifier]:version.
+//
+// Nodes of the same artifact share the same key regardless of the scope they are introduced with.
+func (c Coordinate) Key() string {
+	k := c.GroupId + ":" + c.ArtifactId
+	if c.Packaging != "" {
+		k += ":" + c.Packaging
+	}
+	if c.Classifier != "" {
+		k += ":" + c.Classifier
+	}
+	return k + ":" + c.Version
+}
+
+// Coordinate without version, i.e., groupId:artifactId[:packaging][:classifier].
+func (c Coordinate) artifactKey() string {
+	k := c.GroupArtifact()
+	if c.Packaging != "" {
+		k += ":" + c.Packaging
+	}
+	if c.Classifier != "" {
+		k += ":" + c.Classifier
+	}
+	return k
+}
+
+// groupId:artifactId of the coordinate.
+func (c Coordinate) GroupArtifact() string {
+	return c.GroupId + ":" + c.ArtifactId
+}
+
+// Coordinate in the format used by dependency:tree.
+func (c Coordinate) String() string {
+	s := c.Key()
+	if c.Scope != "" {
+		s += ":" + c.Scope
+	}
+	if c.Optional {
+		s += " " + optionalSuffix
+	}
+	return s
+}
+
+// Parsed version of the coordinate, versions are ordered as Maven's ComparableVersion.
+func (c Coordinate) ParsedVersion() version.Version {
+	return version.Parse(c.Version)
+}
+
+// Compare version with the other coordinate, returns -1 if c's version is lower, 0 if equal, and 1 if higher.
+func (c Coordinate) CompareVersion(o Coordinate) int {
+	return c.ParsedVersion().Compare(o.ParsedVersion())
+}
+
+// Whether version of the coordinate is within the version range, e.g., [1.0,2.0).
+func (c Coordinate) InRange(r version.Range) bool {
+	return r.Contains(c.ParsedVersion())
+}
+
+// Node attributes of the coordinate, these can be queried using graph.Filter.
+func (c Coordinate) Attrs() map[string]string {
+	attrs := map[string]string{
+		AttrGroup:    c.GroupId,
+		AttrArtifact: c.ArtifactId,
+		AttrVersion:  c.Version,
+	}
+	if c.Packaging != "" {
+		attrs[AttrPackaging] = c.Packaging
+	}
+	if c.Classifier != "" {
+		attrs[AttrClassifier] = c.Classifier
+	}
+	if c.Scope != "" {
+		attrs[AttrScope] = c.Scope
+	}
+	if c.Optional {
+		attrs[AttrOptional] = "true"
+	}
+	return attrs
+}
+
+// Node label of the coordinate, groupId, artifactId and the rest are displayed in separate lines.
+func (c Coordinate) label() string {
+	rest := strings.TrimPrefix(c.Key(), c.GroupArtifact()+":")
+	return c.GroupId + "\n" + c.ArtifactId + "\n" + rest
+}
@@ -0,0 +1,77 @@
+package mvn
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseCoordinate(t *testing.T) {
+	cases := []struct {
+		s    string
+		want Coordinate
+	}{
+		{"com.curtisnewbie:todo-app:jar:2.9", Coordinate{GroupId: "com.curtisnewbie", ArtifactId: "todo-app", Packaging: "jar", Version: "2.9"}},
+		{"junit:junit:jar:3.8.1:test", Coordinate{GroupId: "junit", ArtifactId: "junit", Packaging: "jar", Version: "3.8.1", Scope: ScopeTest}},
+		{"io.netty:netty-transport-native-epoll:jar:linux-x86_64:4.1.86.Final:runtime", Coordinate{GroupId: "io.netty", ArtifactId: "netty-transport-native-epoll", Packaging: "jar", Classifier: "linux-x86_64", Version: "4.1.86.Final", Scope: ScopeRuntime}},
+		{"org.foo:bar:jar:tests:1.0", Coordinate{GroupId: "org.foo", ArtifactId: "bar", Packaging: "jar", Classifier: "tests", Version: "1.0"}},
+		{"mysql:mysql-connector-java:jar:8.0.22:compile (optional)", Coordinate{GroupId: "mysql", ArtifactId: "mysql-connector-java", Packaging: "jar", Version: "8.0.22", Scope: ScopeCompile, Optional: true}},
+		{"org.foo:bar:1.0", Coordinate{GroupId: "org.foo", ArtifactId: "bar", Version: "1.0"}},
+	}
+	for _, c := range cases {
+		v, err := ParseCoordinate(c.s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != c.want {
+			t.Fatalf("'%v' expected %#v, actual %#v", c.s, c.want, v)
+		}
+		if v.String() != c.s {
+			t.Fatalf("'%v' expected to be formatted as is, actual '%v'", c.s, v.String())
+		}
+	}
+
+	for _, s := range []string{"", "junit", "junit:junit", "a:b:c:d:e:f:g", "Downloading from central: https://repo.maven.apache.org"} {
+		if _, err := ParseCoordinate(s); err == nil {
+			t.Fatalf("'%v' should be invalid", s)
+		}
+	}
+}
+
+func TestCoordinateVersion(t *testing.T) {
+	ctn, err := os.ReadFile("../../testdata/verbose_mvn.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := ParseMvnGraph("dependency tree", string(ctn))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, ok := g.HighestVersion("com.fasterxml.jackson.core", "jackson-databind")
+	if !ok || c.Version != "2.12.0" {
+		t.Fatalf("highest version should be 2.12.0, %v", c)
+	}
+	if _, ok := g.HighestVersion("org.foo", "bar"); ok {
+		t.Fatal("org.foo:bar should not be found")
+	}
+
+	nodes, err := g.FindInRange("com.fasterxml.jackson.core", "jackson-databind", "[2.9,2.12)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 1 || g.coords[nodes[0].Id].Version != "2.11.2" {
+		t.Fatalf("only 2.11.2 should be within range, %v", nodes)
+	}
+	if nodes, _ := g.FindInRange("com.fasterxml.jackson.core", "jackson-databind", "2.12"); len(nodes) != 1 {
+		t.Fatalf("2.12 should match 2.12.0, %v", nodes)
+	}
+	if _, err := g.FindInRange("com.fasterxml.jackson.core", "jackson-databind", "[2.9"); err == nil {
+		t.Fatal("range should be invalid")
+	}
+
+	a := Coordinate{GroupId: "org.foo", ArtifactId: "bar", Version: "1.10"}
+	b := Coordinate{GroupId: "org.foo", ArtifactId: "bar", Version: "1.9"}
+	if a.CompareVersion(b) <= 0 {
+		t.Fatal("1.10 should be higher than 1.9")
+	}
+}
@@ -0,0 +1,147 @@
+package mvn
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/curtisnewbie/grapher/graph"
+)
+
+// Kind of dependency edge.
+type EdgeKind string
+
+const (
+	EdgeResolved         EdgeKind = "resolved"              // dependency resolved by maven
+	EdgeOmittedDuplicate EdgeKind = "omitted-for-duplicate" // dependency resolved elsewhere in the tree, only in verbose mode
+	EdgeOmittedConflict  EdgeKind = "omitted-for-conflict"  // dependency lost the version mediation, only in verbose mode
+	EdgeOmittedCycle     EdgeKind = "omitted-for-cycle"     // dependency omitted for cycle, only in verbose mode
+
+	AttrEdgeKind               = "kind"
+	AttrEdgeConflictWith       = "conflictWith"
+	AttrEdgeVersionManagedFrom = "versionManagedFrom"
+	AttrEdgeScopeManagedFrom   = "scopeManagedFrom"
+	AttrEdgeScopeUpdatedFrom   = "scopeUpdatedFrom"
+	AttrEdgeInterModule        = "interModule"
+
+	omittedEdgeColor     = "#c8c2b8"
+	managedEdgeColor     = "#1c7ed6"
+	interModuleEdgeColor = "#2b8a3e"
+)
+
+// Dependency from one node to another, i.e., edge of MvnGraph.
+//
+// Apart from the kind, the annotations are only available in verbose mode, i.e., mvn dependency:tree -Dverbose.
+type Dependency struct {
+	FromId             int
+	ToId               int
+	Kind               EdgeKind
+	Scope              string // scope on this path
+	ConflictWith       string // version that won the mediation, only for EdgeOmittedConflict
+	VersionManagedFrom string // version before dependencyManagement is applied
+	ScopeManagedFrom   string // scope before dependencyManagement is applied
+	ScopeUpdatedFrom   string // scope before it's updated by a nearer declaration
+	InterModule        bool   // whether the dependency is another reactor module
+}
+
+// Whether the dependency is omitted from the resolved tree.
+func (d Dependency) Omitted() bool {
+	return d.Kind != "" && d.Kind != EdgeResolved
+}
+
+func (d Dependency) edge() graph.DEdge {
+	attrs := map[string]string{AttrEdgeKind: string(d.Kind)}
+	labels := []string{}
+	if d.ConflictWith != "" {
+		attrs[AttrEdgeConflictWith] = d.ConflictWith
+		labels = append(labels, "conflict with "+d.ConflictWith)
+	}
+	if d.VersionManagedFrom != "" {
+		attrs[AttrEdgeVersionManagedFrom] = d.VersionManagedFrom
+		labels = append(labels, "managed from "+d.VersionManagedFrom)
+	}
+	if d.ScopeManagedFrom != "" {
+		attrs[AttrEdgeScopeManagedFrom] = d.ScopeManagedFrom
+		labels = append(labels, "scope managed from "+d.ScopeManagedFrom)
+	}
+	if d.ScopeUpdatedFrom != "" {
+		attrs[AttrEdgeScopeUpdatedFrom] = d.ScopeUpdatedFrom
+		labels = append(labels, "scope updated from "+d.ScopeUpdatedFrom)
+	}
+
+	ed := graph.DEdge{FromId: d.FromId, ToId: d.ToId, Label: strings.Join(labels, "\n"), Attrs: attrs}
+	if d.InterModule {
+		attrs[AttrEdgeInterModule] = "true"
+	}
+	if d.Omitted() {
+		ed.Tooltip = string(d.Kind)
+		ed.Style = "dashed"
+		ed.Color = omittedEdgeColor
+	} else if d.InterModule {
+		ed.Style = "bold"
+		ed.Color = interModuleEdgeColor
+	} else if len(labels) > 0 {
+		ed.Color = managedEdgeColor
+	}
+	return ed
+}
+
+// Parse entry of dependency tree (without the indentation), verbose entries are supported, e.g.,
+//
+//	org.springframework:spring-core:jar:5.2.9.RELEASE:compile (version managed from 5.2.8.RELEASE)
+//	(org.slf4j:slf4j-api:jar:1.7.25:compile - omitted for conflict with 1.7.30)
+//	(commons-logging:commons-logging:jar:1.2:compile - version managed from 1.1; omitted for duplicate)
+func parseTreeEntry(s string) (Coordinate, Dependency, error) {
+	s = strings.TrimSpace(s)
+	dep := Dependency{Kind: EdgeResolved}
+	notes := []string{}
+
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		// omitted entry, the notes are separated by ' - '
+		s = s[1 : len(s)-1]
+		if i := strings.Index(s, " - "); i > -1 {
+			notes = append(notes, strings.Split(s[i+3:], ";")...)
+			s = s[:i]
+		}
+	}
+
+	// trailing notes, e.g., ' (version managed from 1.1)'
+	for strings.HasSuffix(s, ")") {
+		i := strings.LastIndex(s, " (")
+		if i < 0 {
+			break
+		}
+		n := s[i+2 : len(s)-1]
+		if n == "optional" {
+			break
+		}
+		notes = append(notes, strings.Split(n, ";")...)
+		s = s[:i]
+	}
+
+	c, err := ParseCoordinate(s)
+	if err != nil {
+		return c, dep, err
+	}
+	dep.Scope = c.Scope
+
+	for _, n := range notes {
+		n = strings.TrimSpace(n)
+		if v, ok := strings.CutPrefix(n, "omitted for conflict with "); ok {
+			dep.Kind = EdgeOmittedConflict
+			dep.ConflictWith = strings.TrimSpace(v)
+		} else if n == "omitted for duplicate" {
+			dep.Kind = EdgeOmittedDuplicate
+		} else if n == "omitted for cycle" {
+			dep.Kind = EdgeOmittedCycle
+		} else if v, ok := strings.CutPrefix(n, "version managed from "); ok {
+			dep.VersionManagedFrom = strings.TrimSpace(v)
+		} else if v, ok := strings.CutPrefix(n, "scope managed from "); ok {
+			dep.ScopeManagedFrom = strings.TrimSpace(v)
+		} else if v, ok := strings.CutPrefix(n, "scope updated from "); ok {
+			dep.ScopeUpdatedFrom = strings.TrimSpace(v)
+		} else if n != "" {
+			return c, dep, fmt.Errorf("unknown annotation '%v' of '%v'", n, s)
+		}
+	}
+	return c, dep, nil
+}
@@ -0,0 +1,264 @@
+package mvn
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/curtisnewbie/grapher/graph"
+)
+
+const (
+	maxDuplicateClassPaths = 20
+	maxFormattedClasses    = 10
+
+	shadowingNodeFillColor = "#ffe3a3" // fill color of artifacts whose copies of the duplicate classes are loaded
+	shadowedNodeFillColor  = "#ffc9c9" // fill color of artifacts whose copies of the duplicate classes are shadowed
+)
+
+// Classes that are present in multiple artifacts on the classpath of a module.
+type DuplicateClasses struct {
+	Module  Coordinate    `json:"module"`
+	Winner  Coordinate    `json:"winner"`  // artifact whose copies are loaded, i.e., the first one on the classpath
+	Sources []ClassSource `json:"sources"` // artifacts that contain the classes, in classpath order
+	Classes []string      `json:"classes"` // fully qualified names of the classes, sorted
+}
+
+// Artifact that contains the duplicate classes.
+type ClassSource struct {
+	Artifact Coordinate `json:"artifact"`
+	Paths    [][]string `json:"paths"` // coordinates on each path from the root, at most 20 paths are included
+	NodeId   int        `json:"-"`
+}
+
+// Find classes that are present in more than one artifact on the runtime classpath (compile and runtime scope) of
+// each module, jars are looked up in the local repository. Classes found in the same set of artifacts are grouped
+// together, the groups are sorted by the positions of the winners on the classpath. Artifacts whose jars are not
+// found are returned as well, modules and artifacts with pom packaging are ignored.
+//
+// The classpath is ordered the way maven does, i.e., preorder traversal of the resolved dependency tree, and the
+// first copy of a class on the classpath wins. Nested classes, module-info and package-info are not reported, and
+// classes under META-INF/versions of multi-release jars are treated as the same classes.
+func (g *MvnGraph) DuplicateClasses(repo string) ([]DuplicateClasses, []Coordinate, error) {
+	classes := map[int][]string{}
+	missing := []Coordinate{}
+	load := func(id int) ([]string, error) {
+		if v, ok := classes[id]; ok {
+			return v, nil
+		}
+		c := g.coords[id]
+		path, ok := artifactFile(repo, c)
+		var names []string
+		if ok {
+			var err error
+			if names, err = jarClasses(path); err != nil {
+				if !errors.Is(err, fs.ErrNotExist) {
+					return nil, fmt.Errorf("failed to read %v, %w", path, err)
+				}
+				missing = append(missing, c)
+			}
+		}
+		classes[id] = names
+		return names, nil
+	}
+
+	var parents map[int][]Dependency
+	dups := []DuplicateClasses{}
+	for _, m := range g.modules {
+		cp := g.runtimeClasspath(m)
+		pos := map[int]int{}
+		found := map[string][]int{} // class -> nodes in classpath order
+		for i, id := range cp {
+			pos[id] = i
+			if g.IsModule(id) {
+				continue
+			}
+			names, err := load(id)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, n := range names {
+				found[n] = append(found[n], id)
+			}
+		}
+
+		var mp map[int][]Dependency // parents following the edges of the module
+		groups := map[string]*DuplicateClasses{}
+		winners := map[string]int{}
+		keys := []string{}
+		for name, ids := range found {
+			if len(ids) < 2 {
+				continue
+			}
+			k := fmt.Sprint(ids)
+			dc, ok := groups[k]
+			if !ok {
+				if parents == nil {
+					parents = g.parentDependencies()
+				}
+				if mp == nil {
+					mp = g.moduleParents(parents, m)
+				}
+				dc = &DuplicateClasses{Module: g.coords[m], Winner: g.coords[ids[0]], Sources: []ClassSource{}}
+				for _, id := range ids {
+					dc.Sources = append(dc.Sources, ClassSource{
+						Artifact: g.coords[id],
+						Paths:    g.paths(mp, id, maxDuplicateClassPaths),
+						NodeId:   id,
+					})
+				}
+				groups[k] = dc
+				winners[k] = ids[0]
+				keys = append(keys, k)
+			}
+			dc.Classes = append(dc.Classes, name)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if wi, wj := pos[winners[keys[i]]], pos[winners[keys[j]]]; wi != wj {
+				return wi < wj
+			}
+			return keys[i] < keys[j]
+		})
+		for _, k := range keys {
+			dc := groups[k]
+			sort.Strings(dc.Classes)
+			dups = append(dups, *dc)
+		}
+	}
+	return dups, missing, nil
+}
+
+// Runtime classpath of the module in the order maven builds it, i.e., preorder traversal of the resolved edges of the
+// module, the module itself is the first one.
+func (g *MvnGraph) runtimeClasspath(module int) []int {
+	cp := []int{}
+	met := map[int]struct{}{}
+	var walk func(id int)
+	walk = func(id int) {
+		if _, ok := met[id]; ok {
+			return
+		}
+		met[id] = struct{}{}
+		cp = append(cp, id)
+		for _, ed := range g.OutEdges(id) {
+			k := [2]int{ed.FromId, ed.ToId}
+			if d := g.deps[k]; containsInt(g.edgeModules[k], module) && !d.Omitted() && packaged(d) {
+				walk(ed.ToId)
+			}
+		}
+	}
+	walk(module)
+	return cp
+}
+
+// Fully qualified names of the classes in the jar.
+func jarClasses(path string) ([]string, error) {
+	if fi, err := os.Stat(path); err != nil {
+		return nil, err
+	} else if fi.IsDir() {
+		return nil, fs.ErrNotExist
+	}
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	names := []string{}
+	met := map[string]struct{}{}
+	for _, f := range r.File {
+		name, ok := strings.CutSuffix(f.Name, ".class")
+		if !ok || f.FileInfo().IsDir() {
+			continue
+		}
+		if after, ok := strings.CutPrefix(name, "META-INF/versions/"); ok {
+			if _, cls, found := strings.Cut(after, "/"); found {
+				name = cls
+			}
+		}
+		if strings.HasPrefix(name, "META-INF/") || strings.Contains(name, "$") {
+			continue
+		}
+		if name == "module-info" || name == "package-info" || strings.HasSuffix(name, "/package-info") {
+			continue
+		}
+		name = strings.ReplaceAll(name, "/", ".")
+		if _, ok := met[name]; ok {
+			continue
+		}
+		met[name] = struct{}{}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// Highlight artifacts that contain duplicate classes and the paths leading to them, the ones whose copies are loaded
+// and the ones whose copies are shadowed are filled with different colors.
+func (g *MvnGraph) HighlightDuplicateClasses(dups []DuplicateClasses) {
+	fills := map[int]string{}
+	tooltips := map[int][]string{}
+	for _, dc := range dups {
+		for i, s := range dc.Sources {
+			if i == 0 {
+				fills[s.NodeId] = shadowingNodeFillColor
+				tooltips[s.NodeId] = append(tooltips[s.NodeId], fmt.Sprintf("%d classes loaded, shadowing %v", len(dc.Classes), dc.Sources[1].Artifact.Key()))
+				continue
+			}
+			if _, ok := fills[s.NodeId]; !ok {
+				fills[s.NodeId] = shadowedNodeFillColor
+			}
+			tooltips[s.NodeId] = append(tooltips[s.NodeId], fmt.Sprintf("%d classes shadowed by %v", len(dc.Classes), dc.Winner.Key()))
+		}
+	}
+	g.Highlight(func(n graph.Node) bool {
+		_, ok := fills[n.Id]
+		return ok
+	})
+	for id, fill := range fills {
+		tooltip := strings.Join(tooltips[id], "\n")
+		g.UpdateNode(id, func(n *graph.Node) {
+			n.FillColor = fill
+			n.Tooltip = tooltip
+		})
+	}
+}
+
+// Format duplicate classes as human-readable text, the artifact whose copies are loaded is marked with '*', at most 10
+// classes are listed for each group.
+func FormatDuplicateClasses(dups []DuplicateClasses) string {
+	b := strings.Builder{}
+	for i, dc := range dups {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(fmt.Sprintf("%v, duplicate classes: %d\n", dc.Module.Key(), len(dc.Classes)))
+		for j, s := range dc.Sources {
+			mark := " "
+			if j == 0 {
+				mark = "*"
+			}
+			b.WriteString(fmt.Sprintf("  %v %v\n", mark, s.Artifact.Key()))
+			for _, p := range s.Paths {
+				b.WriteString("      " + strings.Join(p, " -> ") + "\n")
+			}
+		}
+		for j, c := range dc.Classes {
+			if j >= maxFormattedClasses {
+				b.WriteString(fmt.Sprintf("    ... and %d more\n", len(dc.Classes)-j))
+				break
+			}
+			b.WriteString("    " + c + "\n")
+		}
+	}
+	return b.String()
+}
+
+// Format duplicate classes as JSON.
+func FormatDuplicateClassesJSON(dups []DuplicateClasses) ([]byte, error) {
+	return json.MarshalIndent(dups, "", "  ")
+}
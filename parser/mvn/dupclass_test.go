@@ -0,0 +1,166 @@
+package mvn
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeJar(t *testing.T, path string, entries ...string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for _, e := range entries {
+		if _, err := w.Create(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDuplicateClasses(t *testing.T) {
+	ctn, err := os.ReadFile("../../testdata/verbose_mvn.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := ParseMvnGraph("dependency tree", string(ctn))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := t.TempDir()
+	jars := map[string][]string{
+		"spring-jcl": {
+			"META-INF/MANIFEST.MF",
+			"org/apache/commons/logging/",
+			"org/apache/commons/logging/Log.class",
+			"org/apache/commons/logging/LogFactory.class",
+			"org/apache/commons/logging/LogFactory$1.class",
+			"org/apache/commons/logging/package-info.class",
+		},
+		"commons-logging": {
+			"module-info.class",
+			"org/apache/commons/logging/Log.class",
+			"org/apache/commons/logging/LogFactory.class",
+			"org/apache/commons/logging/LogFactory$1.class",
+			"org/apache/commons/logging/package-info.class",
+			"org/apache/commons/logging/impl/SimpleLog.class",
+		},
+		"httpclient": {"module-info.class", "org/apache/http/HttpEntity.class", "org/apache/http/client/HttpClient.class"},
+		"httpcore":   {"module-info.class", "org/apache/http/HttpEntity.class", "org/apache/http/HttpRequest.class"},
+		"jackson-core": {
+			"com/fasterxml/jackson/core/io/NumberInput.class",
+			"META-INF/versions/11/com/fasterxml/jackson/core/io/NumberInput.class",
+		},
+		// test dependencies are not on the runtime classpath
+		"hamcrest-core": {"org/apache/commons/logging/Log.class"},
+	}
+	for _, n := range g.Nodes() {
+		c := g.coords[n.Id]
+		if g.IsModule(n.Id) || c.ArtifactId == "joda-time" {
+			continue
+		}
+		path, _ := artifactFile(repo, c)
+		writeJar(t, path, jars[c.ArtifactId]...)
+	}
+
+	dups, missing, err := g.DuplicateClasses(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("\n%v", FormatDuplicateClasses(dups))
+	if len(missing) != 1 || missing[0].ArtifactId != "joda-time" {
+		t.Fatalf("joda-time should be missing, %v", missing)
+	}
+	if len(dups) != 2 {
+		t.Fatalf("should have 2 groups of duplicate classes, %#v", dups)
+	}
+
+	// spring-jcl comes first on the classpath through spring-core
+	logging := dups[0]
+	if logging.Winner.ArtifactId != "spring-jcl" || len(logging.Sources) != 2 || logging.Sources[1].Artifact.ArtifactId != "commons-logging" {
+		t.Fatalf("spring-jcl should shadow commons-logging, %#v", logging)
+	}
+	if want := []string{"org.apache.commons.logging.Log", "org.apache.commons.logging.LogFactory"}; !reflect.DeepEqual(logging.Classes, want) {
+		t.Fatalf("unexpected classes, %v", logging.Classes)
+	}
+	// commons-logging is brought in by httpclient and aws-java-sdk-core
+	if paths := logging.Sources[1].Paths; len(paths) != 2 || !strings.Contains(strings.Join(paths[1], " "), "aws-java-sdk-core") {
+		t.Fatalf("unexpected paths of commons-logging, %v", paths)
+	}
+
+	http := dups[1]
+	if http.Winner.ArtifactId != "httpclient" || http.Sources[1].Artifact.ArtifactId != "httpcore" ||
+		!reflect.DeepEqual(http.Classes, []string{"org.apache.http.HttpEntity"}) {
+		t.Fatalf("httpclient should shadow httpcore, %#v", http)
+	}
+
+	g.HighlightDuplicateClasses(dups)
+	if n, _ := g.Node(logging.Sources[0].NodeId); n.FillColor != shadowingNodeFillColor {
+		t.Fatalf("spring-jcl should be highlighted, %#v", n)
+	}
+	if n, _ := g.Node(logging.Sources[1].NodeId); n.FillColor != shadowedNodeFillColor {
+		t.Fatalf("commons-logging should be highlighted, %#v", n)
+	}
+
+	b, err := FormatDuplicateClassesJSON(dups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"winner"`) || !strings.Contains(string(b), "org.apache.http.HttpEntity") {
+		t.Fatalf("unexpected json, %s", b)
+	}
+
+	// corrupted jar is reported
+	path, _ := artifactFile(repo, http.Winner)
+	if err := os.WriteFile(path, []byte("not a jar"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := g.DuplicateClasses(repo); err == nil {
+		t.Fatal("corrupted jar should be reported")
+	}
+}
+
+func TestDuplicateClassesReactor(t *testing.T) {
+	// y and x contain the same class, but only module a depends on both of them
+	tree := `com.e:a:jar:1.0
++- com.e:y:jar:1.0:compile
+\- com.e:x:jar:1.0:compile
+com.e:b:jar:1.0
+\- com.e:y:jar:1.0:compile
+`
+	g, err := ParseMvnGraph("dependency tree", tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := t.TempDir()
+	for _, n := range g.Nodes() {
+		if !g.IsModule(n.Id) {
+			path, _ := artifactFile(repo, g.coords[n.Id])
+			writeJar(t, path, "com/e/Shared.class")
+		}
+	}
+
+	dups, _, err := g.DuplicateClasses(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("\n%v", FormatDuplicateClasses(dups))
+	if len(dups) != 1 || dups[0].Module.ArtifactId != "a" || dups[0].Winner.ArtifactId != "y" {
+		t.Fatalf("y should shadow x in module a, %#v", dups)
+	}
+	if paths := dups[0].Sources[0].Paths; len(paths) != 1 || paths[0][0] != "com.e:a:jar:1.0" {
+		t.Fatalf("paths of y should be in module a, %v", paths)
+	}
+}
@@ -0,0 +1,29 @@
+package mvn
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNoDependencyTree = errors.New("no dependency tree found")
+	ErrInvalidEntry     = errors.New("invalid dependency tree entry")
+	ErrInvalidIndent    = errors.New("invalid dependency tree indentation")
+	ErrPomNotFound      = errors.New("pom not found")
+	ErrNotVerbose       = errors.New("dependencies omitted for conflict are not available, graph is not verbose")
+)
+
+// Error of a specific line in the output of mvn dependency:tree.
+type LineError struct {
+	Line int    // line number, starting from 1
+	Text string // raw text of the line
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v, '%v'", e.Line, e.Err, e.Text)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,223 @@
+package mvn
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/curtisnewbie/grapher/graph"
+)
+
+// Suggested exclusions to be added to a direct dependency.
+type ExclusionSuggestion struct {
+	Module     Coordinate   `json:"module"`        // module that declares the direct dependency
+	Pom        string       `json:"pom,omitempty"` // pom.xml of the module, only available if parsed from pom.xml
+	Dependency Coordinate   `json:"dependency"`    // direct dependency that brings in the artifacts
+	Exclusions []Exclusion  `json:"exclusions"`
+	Excluded   []Coordinate `json:"excluded"` // artifacts excluded by the exclusions
+
+	moduleId     int
+	dependencyId int
+	excludedIds  []int
+}
+
+// Suggestions of exclusions for unwanted artifacts.
+type ExclusionReport struct {
+	Pattern     string                `json:"pattern"`
+	Suggestions []ExclusionSuggestion `json:"suggestions"`
+	Declared    []Coordinate          `json:"declared"` // unwanted artifacts that are declared as direct dependencies, these should be removed instead
+}
+
+// Snippet of the dependency with exclusions that can be pasted into pom.xml, the leading comment names the module (and
+// its pom.xml if available) and the resolved version of the dependency, so that the declaration can be told apart
+// from the managed or duplicate ones.
+func (s ExclusionSuggestion) XML() string {
+	b := strings.Builder{}
+	declared := s.Module.Key()
+	if s.Pom != "" {
+		declared += " (" + s.Pom + ")"
+	}
+	b.WriteString(fmt.Sprintf("<!-- declared in %v, resolved version: %v -->\n", declared, s.Dependency.Version))
+	b.WriteString("<dependency>\n")
+	b.WriteString(fmt.Sprintf("  <groupId>%v</groupId>\n", s.Dependency.GroupId))
+	b.WriteString(fmt.Sprintf("  <artifactId>%v</artifactId>\n", s.Dependency.ArtifactId))
+	b.WriteString("  <exclusions>\n")
+	for _, e := range s.Exclusions {
+		b.WriteString("    <exclusion>\n")
+		b.WriteString(fmt.Sprintf("      <groupId>%v</groupId>\n", e.GroupId))
+		b.WriteString(fmt.Sprintf("      <artifactId>%v</artifactId>\n", e.ArtifactId))
+		b.WriteString("    </exclusion>\n")
+	}
+	b.WriteString("  </exclusions>\n")
+	b.WriteString("</dependency>\n")
+	return b.String()
+}
+
+// Suggest exclusions for artifacts matching the groupId[:artifactId] pattern, '*' and '?' are supported.
+//
+// For each module, the suggested direct dependencies are the minimal set that the artifacts arrive through. A direct
+// dependency is not suggested if it only reaches the artifacts through other direct dependencies, because these
+// transitive occurrences are omitted by maven as duplicates.
+func (g *MvnGraph) SuggestExclusions(pattern string) (*ExclusionReport, error) {
+	ap, err := parseArtifactPattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(ap.parts) > 2 {
+		return nil, fmt.Errorf("invalid artifact pattern '%v', should be groupId[:artifactId]", pattern)
+	}
+	report := &ExclusionReport{Pattern: pattern, Suggestions: []ExclusionSuggestion{}, Declared: []Coordinate{}}
+
+	targets := map[int]struct{}{}
+	for _, n := range g.Nodes() {
+		if c, ok := g.coords[n.Id]; ok && !g.IsModule(n.Id) && ap.match(c) {
+			targets[n.Id] = struct{}{}
+		}
+	}
+	if len(targets) < 1 {
+		return report, nil
+	}
+
+	for _, m := range g.modules {
+		direct := g.directDependencies(m)
+		for _, d := range direct {
+			if _, ok := targets[d]; ok {
+				report.Declared = append(report.Declared, g.coords[d])
+				continue
+			}
+			// candidates are found by reachability first, then refined by walking around other direct dependencies
+			reachable := false
+			for _, id := range g.reachable(m, d, nil, nil) {
+				if _, ok := targets[id]; ok {
+					reachable = true
+					break
+				}
+			}
+			if !reachable {
+				continue
+			}
+			s := ExclusionSuggestion{Module: g.coords[m], Pom: g.modulePoms[g.coords[m].Key()], Dependency: g.coords[d], moduleId: m, dependencyId: d}
+			seen := map[string]struct{}{}
+			for _, id := range g.reachable(m, d, direct, nil) {
+				if _, ok := targets[id]; !ok {
+					continue
+				}
+				c := g.coords[id]
+				s.Excluded = append(s.Excluded, c)
+				s.excludedIds = append(s.excludedIds, id)
+				if _, ok := seen[c.GroupArtifact()]; !ok {
+					seen[c.GroupArtifact()] = struct{}{}
+					s.Exclusions = append(s.Exclusions, Exclusion{GroupId: c.GroupId, ArtifactId: c.ArtifactId})
+				}
+			}
+			if len(s.Exclusions) > 0 {
+				report.Suggestions = append(report.Suggestions, s)
+			}
+		}
+	}
+	return report, nil
+}
+
+// Direct dependencies of the module, in the order they are declared.
+func (g *MvnGraph) directDependencies(module int) []int {
+	ids := []int{}
+	for _, ed := range g.OutEdges(module) {
+		if containsInt(g.edgeModules[[2]int{ed.FromId, ed.ToId}], module) {
+			ids = append(ids, ed.ToId)
+		}
+	}
+	return ids
+}
+
+// Nodes reachable from the node through edges of the module without passing through the stop nodes or the excluded
+// nodes, the node itself is included.
+func (g *MvnGraph) reachable(module int, from int, stop []int, excluded map[int]struct{}) []int {
+	stops := map[int]struct{}{}
+	for _, s := range stop {
+		stops[s] = struct{}{}
+	}
+	met := map[int]struct{}{from: {}}
+	ids := []int{from}
+	queue := []int{from}
+	for len(queue) > 0 {
+		pop := queue[0]
+		queue = queue[1:]
+		for _, ed := range g.OutEdges(pop) {
+			c := ed.ToId
+			if _, ok := met[c]; ok || !containsInt(g.edgeModules[[2]int{pop, c}], module) {
+				continue
+			}
+			if _, ok := stops[c]; ok {
+				continue
+			}
+			if _, ok := excluded[c]; ok {
+				continue
+			}
+			met[c] = struct{}{}
+			ids = append(ids, c)
+			queue = append(queue, c)
+		}
+	}
+	return ids
+}
+
+// Preview graph with the suggested exclusions applied, artifacts that are no longer reachable are removed.
+//
+// The report must be created by SuggestExclusions of the same graph, node ids are preserved.
+func (g *MvnGraph) ApplyExclusions(report *ExclusionReport) (*MvnGraph, error) {
+	suggested := map[[2]int]map[int]struct{}{} // [module, direct dependency] -> excluded nodes
+	for _, s := range report.Suggestions {
+		ex := map[int]struct{}{}
+		for _, id := range s.excludedIds {
+			ex[id] = struct{}{}
+		}
+		suggested[[2]int{s.moduleId, s.dependencyId}] = ex
+	}
+
+	nodes := map[int]struct{}{}
+	edges := map[[2]int]struct{}{}
+	for _, m := range g.modules {
+		nodes[m] = struct{}{}
+		direct := g.directDependencies(m)
+		for _, d := range direct {
+			edges[[2]int{m, d}] = struct{}{}
+			ex := suggested[[2]int{m, d}]
+			for _, id := range g.reachable(m, d, direct, ex) {
+				nodes[id] = struct{}{}
+				for _, ed := range g.OutEdges(id) {
+					if _, ok := ex[ed.ToId]; !ok && containsInt(g.edgeModules[[2]int{id, ed.ToId}], m) {
+						edges[[2]int{id, ed.ToId}] = struct{}{}
+					}
+				}
+			}
+		}
+	}
+	return g.filter(g.Title(), func(n graph.Node) bool {
+		_, ok := nodes[n.Id]
+		return ok
+	}, func(ed graph.DEdge) bool {
+		_, ok := edges[[2]int{ed.FromId, ed.ToId}]
+		return ok
+	})
+}
+
+// Format exclusion report as human-readable text with XML snippets.
+func FormatExclusions(r *ExclusionReport) string {
+	b := strings.Builder{}
+	for _, c := range r.Declared {
+		b.WriteString(fmt.Sprintf("%v is declared as direct dependency, remove the declaration instead\n\n", c.Key()))
+	}
+	for _, s := range r.Suggestions {
+		b.WriteString(fmt.Sprintf("In %v, %v brings in:\n", s.Module.Key(), s.Dependency.Key()))
+		for _, c := range s.Excluded {
+			b.WriteString(fmt.Sprintf("  %v\n", c.Key()))
+		}
+		b.WriteString("\n" + s.XML() + "\n")
+	}
+	return b.String()
+}
+
+// Format exclusion report as JSON.
+func FormatExclusionsJSON(r *ExclusionReport) ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
@@ -0,0 +1,153 @@
+package mvn
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSuggestExclusions(t *testing.T) {
+	ctn, err := os.ReadFile("../../testdata/verbose_mvn.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := ParseMvnGraph("dependency tree", string(ctn))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := g.SuggestExclusions("commons-logging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("\n%v", FormatExclusions(r))
+	if len(r.Declared) != 0 {
+		t.Fatalf("commons-logging is not declared directly, %v", r.Declared)
+	}
+	if len(r.Suggestions) != 2 {
+		t.Fatalf("should suggest 2 dependencies, %#v", r.Suggestions)
+	}
+	// aws-java-sdk-core depends on commons-logging directly, it must be excluded as well, otherwise maven picks it up
+	if a := r.Suggestions[0].Dependency.ArtifactId; a != "httpclient" {
+		t.Fatalf("should suggest httpclient first, %v", a)
+	}
+	if a := r.Suggestions[1].Dependency.ArtifactId; a != "aws-java-sdk-core" {
+		t.Fatalf("should suggest aws-java-sdk-core second, %v", a)
+	}
+	want := `<!-- declared in com.curtisnewbie:order-app:jar:1.0.0, resolved version: 4.5.13 -->
+<dependency>
+  <groupId>org.apache.httpcomponents</groupId>
+  <artifactId>httpclient</artifactId>
+  <exclusions>
+    <exclusion>
+      <groupId>commons-logging</groupId>
+      <artifactId>commons-logging</artifactId>
+    </exclusion>
+  </exclusions>
+</dependency>
+`
+	if x := r.Suggestions[0].XML(); x != want {
+		t.Fatalf("unexpected snippet\n%v", x)
+	}
+
+	preview, err := g.ApplyExclusions(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := preview.FindArtifact("commons-logging", "commons-logging"); len(n) != 0 {
+		t.Fatalf("commons-logging should be removed, %v", n)
+	}
+	if n := preview.FindArtifact("commons-codec", "commons-codec"); len(n) != 1 {
+		t.Fatalf("commons-codec should be kept, %v", n)
+	}
+	if preview.NodeCount() != g.NodeCount()-1 {
+		t.Fatalf("only commons-logging should be removed, %v -> %v", g.NodeCount(), preview.NodeCount())
+	}
+
+	r, err = g.SuggestExclusions("junit:junit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Declared) != 1 || len(r.Suggestions) != 0 {
+		t.Fatalf("junit is declared directly, %#v", r)
+	}
+
+	if _, err := g.SuggestExclusions("a:b:c"); err == nil {
+		t.Fatal("version should not be accepted in pattern")
+	}
+}
+
+func TestSuggestExclusionsResolved(t *testing.T) {
+	g, err := ResolvePom("shop", "../../testdata/pom/shop", PomParam{Repo: "../../testdata/m2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := g.SuggestExclusions("commons-codec:*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("\n%v", FormatExclusions(r))
+	if len(r.Suggestions) != 1 {
+		t.Fatalf("should suggest 1 dependency, %#v", r.Suggestions)
+	}
+	s := r.Suggestions[0]
+	if s.Module.ArtifactId != "shop-service" || s.Dependency.ArtifactId != "legacy-client" {
+		t.Fatalf("should suggest legacy-client of shop-service, %#v", s)
+	}
+	if len(s.Exclusions) != 1 || s.Exclusions[0].ArtifactId != "commons-codec" {
+		t.Fatalf("unexpected exclusions, %#v", s.Exclusions)
+	}
+	if x := s.XML(); !strings.HasPrefix(x, "<!-- declared in com.example.shop:shop-service:jar:1.0.0 (") ||
+		!strings.Contains(x, "shop-service/pom.xml), resolved version: 1.0 -->") {
+		t.Fatalf("unexpected snippet\n%v", x)
+	}
+
+	preview, err := g.ApplyExclusions(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range preview.Nodes() {
+		if strings.Contains(n.Label, "commons-codec") {
+			t.Fatalf("commons-codec should be removed, %v", n.Label)
+		}
+	}
+	if len(preview.Modules()) != 3 {
+		t.Fatalf("modules should be kept, %v", preview.Modules())
+	}
+}
+
+func TestSuggestExclusionsReactor(t *testing.T) {
+	// y brings in commons-logging in module a, but not in module b
+	tree := `com.e:a:jar:1.0
+\- com.e:y:jar:1.0:compile
+   \- commons-logging:commons-logging:jar:1.2:compile
+com.e:b:jar:1.0
+\- com.e:y:jar:1.0:compile
+`
+	g, err := ParseMvnGraph("dependency tree", tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Modules()) != 2 {
+		t.Fatalf("should have 2 modules, %v", g.Modules())
+	}
+	r, err := g.SuggestExclusions("commons-logging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("\n%v", FormatExclusions(r))
+	if len(r.Suggestions) != 1 || r.Suggestions[0].Module.ArtifactId != "a" || r.Suggestions[0].Dependency.ArtifactId != "y" {
+		t.Fatalf("should only suggest y of module a, %#v", r.Suggestions)
+	}
+
+	preview, err := g.ApplyExclusions(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := preview.FindArtifact("commons-logging", "commons-logging"); len(n) != 0 {
+		t.Fatalf("commons-logging should be removed, %v", n)
+	}
+	if n := preview.FindArtifact("com.e", "y"); len(n) != 1 {
+		t.Fatalf("y should be kept, %v", n)
+	}
+}
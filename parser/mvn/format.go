@@ -0,0 +1,419 @@
+package mvn
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Output type of mvn dependency:tree, i.e., -DoutputType.
+type Format string
+
+const (
+	FormatText    Format = "text"
+	FormatDot     Format = "dot"
+	FormatGraphML Format = "graphml"
+	FormatTGF     Format = "tgf"
+	FormatJSON    Format = "json"
+)
+
+var (
+	dotGraphRegex = regexp.MustCompile(`^\s*digraph\s+"([^"]*)"`)
+	dotEdgeRegex  = regexp.MustCompile(`^\s*"([^"]+)"\s*->\s*"([^"]+)"`)
+)
+
+// Detect format of the mvn dependency:tree output based on the beginning of the content.
+func DetectFormat(s string) Format {
+	s = strings.TrimLeft(strings.TrimPrefix(s, "\ufeff"), " \t\r\n")
+	switch {
+	case strings.HasPrefix(s, "{"):
+		return FormatJSON
+	case strings.HasPrefix(s, "<?xml"), strings.HasPrefix(s, "<graphml"):
+		return FormatGraphML
+	case strings.HasPrefix(s, "digraph"):
+		return FormatDot
+	}
+
+	// e.g., '1919892312 com.curtisnewbie:todo-app:jar:2.9'
+	first, _, _ := strings.Cut(s, "\n")
+	if f := strings.Fields(first); len(f) == 2 && strings.Contains(f[1], ":") {
+		if _, err := strconv.ParseInt(f[0], 10, 64); err == nil {
+			return FormatTGF
+		}
+	}
+	return FormatText
+}
+
+// Parse output of mvn dependency:tree, the format is detected automatically, see DetectFormat.
+func Parse(title string, s string) (*MvnGraph, error) {
+	return ParseFormat(title, s, DetectFormat(s))
+}
+
+// Parse output of mvn dependency:tree in the given format.
+func ParseFormat(title string, s string, f Format) (*MvnGraph, error) {
+	switch f {
+	case FormatText:
+		return ParseMvnGraph(title, s)
+	case FormatDot:
+		return parseDot(title, strings.NewReader(s))
+	case FormatGraphML:
+		return parseGraphML(title, strings.NewReader(s))
+	case FormatTGF:
+		return parseTGF(title, strings.NewReader(s))
+	case FormatJSON:
+		return parseJSON(title, strings.NewReader(s))
+	}
+	return nil, fmt.Errorf("unsupported format '%v'", f)
+}
+
+// Connect parent to the dependency identified by the given coordinate, the scope of the edge is used if the
+// coordinate doesn't have one.
+func (b *treeBuilder) connectCoordinate(p *treeEntry, s string, scope string) (*treeEntry, error) {
+	c, err := ParseCoordinate(s)
+	if err != nil {
+		return nil, err
+	}
+	if c.Scope == "" {
+		c.Scope = scope
+	}
+	d := b.add(c, p.layer+1)
+	b.connect(p, d, Dependency{Kind: EdgeResolved, Scope: c.Scope})
+	return d, nil
+}
+
+// Parse -DoutputType=dot, e.g.,
+//
+//	digraph "com.curtisnewbie:todo-app:jar:2.9" {
+//		"com.curtisnewbie:todo-app:jar:2.9" -> "junit:junit:jar:3.8.1:test" ;
+//	 }
+func parseDot(title string, r io.Reader) (*MvnGraph, error) {
+	b := newTreeBuilder()
+	sc := newLineScanner(r)
+	ln := 0
+	for sc.Scan() {
+		ln++
+		l := sc.Text()
+		if m := dotGraphRegex.FindStringSubmatch(l); m != nil {
+			c, err := ParseCoordinate(m[1])
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse dot at line %d, %w", ln, err)
+			}
+			b.addRoot(c)
+			continue
+		}
+		m := dotEdgeRegex.FindStringSubmatch(l)
+		if m == nil {
+			continue
+		}
+		pc, err := ParseCoordinate(m[1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse dot at line %d, %w", ln, err)
+		}
+		p, ok := b.entryMap[pc.Key()]
+		if !ok {
+			p = b.addRoot(pc)
+		}
+		if _, err := b.connectCoordinate(p, m[2], ""); err != nil {
+			return nil, fmt.Errorf("failed to parse dot at line %d, %w", ln, err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dot, %w", err)
+	}
+	return b.build(title)
+}
+
+// Parse -DoutputType=tgf, e.g.,
+//
+//	1919892312 com.curtisnewbie:todo-app:jar:2.9
+//	1205555397 junit:junit:jar:3.8.1:test
+//	#
+//	1919892312 1205555397 test
+//
+// Outputs of multiple modules may be appended to the same file.
+func parseTGF(title string, r io.Reader) (*MvnGraph, error) {
+	b := newTreeBuilder()
+	sc := newLineScanner(r)
+	ln := 0
+	ids := map[string]*treeEntry{}
+	inEdges := false
+	for sc.Scan() {
+		ln++
+		l := strings.TrimSpace(sc.Text())
+		if l == "" {
+			continue
+		}
+		if l == "#" {
+			inEdges = true
+			continue
+		}
+		f := strings.Fields(l)
+		if len(f) == 2 && strings.Contains(f[1], ":") {
+			if inEdges { // next module
+				inEdges = false
+				ids = map[string]*treeEntry{}
+			}
+			c, err := ParseCoordinate(f[1])
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse tgf at line %d, %w", ln, err)
+			}
+			if len(ids) < 1 {
+				ids[f[0]] = b.addRoot(c) // the first node is the root
+			} else {
+				ids[f[0]] = b.add(c, 0)
+			}
+			continue
+		}
+		if !inEdges || len(f) < 2 {
+			return nil, fmt.Errorf("failed to parse tgf at line %d, unrecognized line '%v'", ln, l)
+		}
+		p, ok := ids[f[0]]
+		if !ok {
+			return nil, fmt.Errorf("failed to parse tgf at line %d, node '%v' not found", ln, f[0])
+		}
+		d, ok := ids[f[1]]
+		if !ok {
+			return nil, fmt.Errorf("failed to parse tgf at line %d, node '%v' not found", ln, f[1])
+		}
+		scope := ""
+		if len(f) > 2 {
+			scope = f[2]
+		}
+		b.connect(p, d, Dependency{Kind: EdgeResolved, Scope: scope})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tgf, %w", err)
+	}
+	return b.build(title)
+}
+
+type graphMLDoc struct {
+	Graphs []struct {
+		Nodes []struct {
+			Id    string `xml:"id,attr"`
+			Label string `xml:"data>ShapeNode>NodeLabel"`
+		} `xml:"node"`
+		Edges []struct {
+			Source string `xml:"source,attr"`
+			Target string `xml:"target,attr"`
+			Label  string `xml:"data>PolyLineEdge>EdgeLabel"`
+		} `xml:"edge"`
+	} `xml:"graph"`
+}
+
+// Parse -DoutputType=graphml.
+func parseGraphML(title string, r io.Reader) (*MvnGraph, error) {
+	doc := graphMLDoc{}
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse graphml, %w", err)
+	}
+	b := newTreeBuilder()
+	for _, g := range doc.Graphs {
+		ids := map[string]*treeEntry{}
+		for _, n := range g.Nodes {
+			c, err := ParseCoordinate(n.Label)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse graphml node '%v', %w", n.Id, err)
+			}
+			if len(ids) < 1 {
+				ids[n.Id] = b.addRoot(c) // the first node is the root
+			} else {
+				ids[n.Id] = b.add(c, 0)
+			}
+		}
+		for _, ed := range g.Edges {
+			p, ok := ids[ed.Source]
+			if !ok {
+				return nil, fmt.Errorf("failed to parse graphml edge, node '%v' not found", ed.Source)
+			}
+			d, ok := ids[ed.Target]
+			if !ok {
+				return nil, fmt.Errorf("failed to parse graphml edge, node '%v' not found", ed.Target)
+			}
+			b.connect(p, d, Dependency{Kind: EdgeResolved, Scope: strings.TrimSpace(ed.Label)})
+		}
+	}
+	return b.build(title)
+}
+
+type jsonNode struct {
+	GroupId    string     `json:"groupId"`
+	ArtifactId string     `json:"artifactId"`
+	Version    string     `json:"version"`
+	Type       string     `json:"type"`
+	Scope      string     `json:"scope"`
+	Classifier string     `json:"classifier"`
+	Optional   string     `json:"optional"`
+	Children   []jsonNode `json:"children"`
+}
+
+func (n jsonNode) coordinate() (Coordinate, error) {
+	c := Coordinate{
+		GroupId:    n.GroupId,
+		ArtifactId: n.ArtifactId,
+		Packaging:  n.Type,
+		Classifier: n.Classifier,
+		Version:    n.Version,
+		Scope:      n.Scope,
+		Optional:   n.Optional == "true",
+	}
+	if c.GroupId == "" || c.ArtifactId == "" || c.Version == "" {
+		return c, fmt.Errorf("invalid coordinate '%v'", c)
+	}
+	return c, nil
+}
+
+// Parse -DoutputType=json, outputs of multiple modules may be appended to the same file.
+func parseJSON(title string, r io.Reader) (*MvnGraph, error) {
+	b := newTreeBuilder()
+	var walk func(p *treeEntry, n jsonNode, layer int) error
+	walk = func(p *treeEntry, n jsonNode, layer int) error {
+		c, err := n.coordinate()
+		if err != nil {
+			return err
+		}
+		var v *treeEntry
+		if p == nil {
+			v = b.addRoot(c)
+		} else {
+			v = b.add(c, layer)
+			b.connect(p, v, Dependency{Kind: EdgeResolved, Scope: c.Scope})
+		}
+		for _, ch := range n.Children {
+			if err := walk(v, ch, layer+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	dec := json.NewDecoder(r)
+	for {
+		root := jsonNode{}
+		if err := dec.Decode(&root); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("failed to parse json, %w", err)
+		}
+		if err := walk(nil, root, 0); err != nil {
+			return nil, fmt.Errorf("failed to parse json, %w", err)
+		}
+	}
+	return b.build(title)
+}
+
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	return sc
+}
+
+// Progress of ParseReader.
+type Progress struct {
+	Lines int   // lines read
+	Bytes int64 // bytes read
+	Nodes int   // nodes parsed so far, only available for FormatText
+}
+
+type ReaderParam struct {
+	Format           Format           // format of the output, by default it's detected automatically.
+	Progress         func(p Progress) // optional, called every ProgressInterval lines and when the parsing is finished.
+	ProgressInterval int              // by default it's 10000 lines.
+}
+
+// Parse output of mvn dependency:tree from the reader line by line, e.g., stdout of mvn process or a large output file.
+//
+// Unlike Parse, the content is never fully loaded into memory.
+func ParseReader(title string, r io.Reader, p ReaderParam) (*MvnGraph, error) {
+	if p.ProgressInterval < 1 {
+		p.ProgressInterval = 10000
+	}
+	br := bufio.NewReaderSize(r, 64*1024)
+	if p.Format == "" {
+		peek, err := br.Peek(4096)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+			return nil, fmt.Errorf("failed to read dependency tree, %w", err)
+		}
+		p.Format = DetectFormat(string(peek))
+	}
+
+	cr := &progressReader{r: br, p: p}
+	var g *MvnGraph
+	var err error
+	switch p.Format {
+	case FormatText:
+		tp := newTextParser()
+		cr.nodes = func() int { return len(tp.b.entries) }
+		g, err = parseText(title, cr, tp)
+	case FormatDot:
+		g, err = parseDot(title, cr)
+	case FormatGraphML:
+		g, err = parseGraphML(title, cr)
+	case FormatTGF:
+		g, err = parseTGF(title, cr)
+	case FormatJSON:
+		g, err = parseJSON(title, cr)
+	default:
+		return nil, fmt.Errorf("unsupported format '%v'", p.Format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	cr.report()
+	return g, nil
+}
+
+func parseText(title string, r io.Reader, tp *textParser) (*MvnGraph, error) {
+	sc := newLineScanner(r)
+	ln := 0
+	for sc.Scan() {
+		ln++
+		if err := tp.feed(ln, sc.Text()); err != nil {
+			return nil, err
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dependency tree, %w", err)
+	}
+	return tp.finish(title)
+}
+
+// Reader that counts lines and bytes read to report progress.
+type progressReader struct {
+	r     io.Reader
+	p     ReaderParam
+	lines int
+	bytes int64
+	next  int
+	nodes func() int
+}
+
+func (c *progressReader) Read(b []byte) (int, error) {
+	n, err := c.r.Read(b)
+	c.bytes += int64(n)
+	c.lines += bytes.Count(b[:n], []byte{'\n'})
+	if c.p.Progress != nil && c.lines >= c.next+c.p.ProgressInterval {
+		c.next = c.lines - c.lines%c.p.ProgressInterval
+		c.report()
+	}
+	return n, err
+}
+
+func (c *progressReader) report() {
+	if c.p.Progress == nil {
+		return
+	}
+	pg := Progress{Lines: c.lines, Bytes: c.bytes}
+	if c.nodes != nil {
+		pg.Nodes = c.nodes()
+	}
+	c.p.Progress(pg)
+}
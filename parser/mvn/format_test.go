@@ -0,0 +1,111 @@
+package mvn
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestParseFormat(t *testing.T) {
+	ctn, err := os.ReadFile("../../testdata/todoapp_mvn.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tg, err := ParseMvnGraph("dependency tree", string(ctn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := tg.SDraw()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for f, file := range map[Format]string{
+		FormatText:    "../../testdata/todoapp_mvn.out",
+		FormatDot:     "../../testdata/todoapp_mvn.dot",
+		FormatTGF:     "../../testdata/todoapp_mvn.tgf",
+		FormatGraphML: "../../testdata/todoapp_mvn.graphml",
+		FormatJSON:    "../../testdata/todoapp_mvn.json",
+	} {
+		ctn, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d := DetectFormat(string(ctn)); d != f {
+			t.Fatalf("%v should be detected as %v, but is %v", file, f, d)
+		}
+		g, err := Parse("dependency tree", string(ctn))
+		if err != nil {
+			t.Fatalf("failed to parse %v, %v", file, err)
+		}
+		s, err := g.SDraw()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s != want {
+			t.Fatalf("%v is parsed differently, expected:\n%v\nactual:\n%v", file, want, s)
+		}
+		for _, n := range g.FindArtifact("junit", "junit") {
+			if c, _ := g.Coordinate(n.Id); c.Scope != ScopeTest {
+				t.Fatalf("%v: junit should be in test scope, %#v", file, c)
+			}
+		}
+	}
+
+	if _, err := ParseFormat("dependency tree", "1 a:b:jar:1\n#\n1 2 compile", FormatTGF); err == nil {
+		t.Fatal("should fail on missing node")
+	}
+}
+
+func TestParseReader(t *testing.T) {
+	// generate a large reactor output without loading it into memory
+	pr, pw := io.Pipe()
+	go func() {
+		w := bufio.NewWriter(pw)
+		for m := 0; m < 200; m++ {
+			fmt.Fprintf(w, "[INFO] --- dependency:3.6.1:tree (default-cli) @ module-%d ---\n", m)
+			fmt.Fprintf(w, "[INFO] com.curtisnewbie:module-%d:jar:1.0\n", m)
+			for d := 0; d < 100; d++ {
+				fmt.Fprintf(w, "[INFO] +- org.lib%d:lib-%d:jar:1.%d:compile\n", d, d, m%3)
+				fmt.Fprintf(w, "[INFO] |  \\- org.lib%d:lib-%d-core:jar:1.%d:compile\n", d, d, m%3)
+			}
+			fmt.Fprintf(w, "[INFO] ------------------------------------------------------------------------\n")
+		}
+		w.Flush()
+		pw.Close()
+	}()
+
+	reports := []Progress{}
+	g, err := ParseReader("dependency tree", pr, ReaderParam{
+		ProgressInterval: 5000,
+		Progress:         func(p Progress) { reports = append(reports, p) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.NodeCount() != 200+3*200 {
+		t.Fatalf("unexpected node count %v", g.NodeCount())
+	}
+	if len(reports) < 8 {
+		t.Fatalf("should report progress periodically, %v", reports)
+	}
+	last := reports[len(reports)-1]
+	if last.Lines != 200*203 || last.Nodes != g.NodeCount() {
+		t.Fatalf("unexpected final progress, %+v", last)
+	}
+
+	ctn, err := os.ReadFile("../../testdata/todoapp_mvn.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err = ParseReader("dependency tree", bytes.NewReader(ctn), ReaderParam{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.NodeCount() != 21 {
+		t.Fatalf("unexpected node count %v", g.NodeCount())
+	}
+}
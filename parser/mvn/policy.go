@@ -0,0 +1,368 @@
+package mvn
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/curtisnewbie/grapher/graph"
+	"github.com/curtisnewbie/grapher/parser/mvn/version"
+)
+
+const (
+	RuleDependencyConvergence = "dependencyConvergence"
+	RuleRequireUpperBoundDeps = "requireUpperBoundDeps"
+	RuleBannedDependencies    = "bannedDependencies"
+	RuleNoSnapshots           = "noSnapshots"
+	RuleMaxDepth              = "maxDepth"
+
+	snapshotSuffix = "-SNAPSHOT"
+)
+
+// Policy checked against MvnGraph, rules are similar to the ones provided by maven-enforcer-plugin.
+//
+// Policy is usually loaded from a JSON file, e.g.,
+//
+//	{
+//	  "dependencyConvergence": true,
+//	  "requireUpperBoundDeps": true,
+//	  "bannedDependencies": ["log4j:log4j", "commons-logging:*", "*:*:*-SNAPSHOT"],
+//	  "noSnapshots": true,
+//	  "maxDepth": 6
+//	}
+type Policy struct {
+	DependencyConvergence bool     `json:"dependencyConvergence"` // all versions of the same artifact must converge
+	RequireUpperBoundDeps bool     `json:"requireUpperBoundDeps"` // selected version must not be lower than any requested version
+	BannedDependencies    []string `json:"bannedDependencies"`    // groupId[:artifactId[:version]] patterns, '*' and '?' are supported
+	NoSnapshots           bool     `json:"noSnapshots"`           // release modules must not depend on snapshots
+	MaxDepth              int      `json:"maxDepth"`              // max depth of transitive dependencies, 0 means no limit
+}
+
+// Violation of a policy rule.
+type Violation struct {
+	Rule     string     `json:"rule"`
+	Message  string     `json:"message"`
+	Artifact string     `json:"artifact"`
+	NodeIds  []int      `json:"-"`
+	Paths    [][]string `json:"paths"` // coordinates on each path from the root to the artifact
+}
+
+// Load policy from JSON file.
+func LoadPolicy(path string) (*Policy, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p Policy
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy %v, %w", path, err)
+	}
+	for _, pat := range p.BannedDependencies {
+		if _, err := parseArtifactPattern(pat); err != nil {
+			return nil, fmt.Errorf("invalid policy %v, %w", path, err)
+		}
+	}
+	return &p, nil
+}
+
+// Rules enabled in the policy.
+func (p *Policy) Rules() []string {
+	rules := []string{}
+	if p.DependencyConvergence {
+		rules = append(rules, RuleDependencyConvergence)
+	}
+	if p.RequireUpperBoundDeps {
+		rules = append(rules, RuleRequireUpperBoundDeps)
+	}
+	if len(p.BannedDependencies) > 0 {
+		rules = append(rules, RuleBannedDependencies)
+	}
+	if p.NoSnapshots {
+		rules = append(rules, RuleNoSnapshots)
+	}
+	if p.MaxDepth > 0 {
+		rules = append(rules, RuleMaxDepth)
+	}
+	return rules
+}
+
+// Check graph against the policy, violations are grouped by rules.
+//
+// dependencyConvergence and requireUpperBoundDeps rely on dependencies omitted for conflict, ErrNotVerbose is returned
+// if they are enabled but the graph is not verbose, so that the check doesn't silently pass.
+func (p *Policy) Check(g *MvnGraph) ([]Violation, error) {
+	violations := []Violation{}
+	parents := g.parentDependencies()
+
+	conflicts := []Conflict{}
+	if p.DependencyConvergence || p.RequireUpperBoundDeps {
+		if !g.Verbose() {
+			return nil, fmt.Errorf("%v and %v require mvn dependency:tree -Dverbose, %w", RuleDependencyConvergence,
+				RuleRequireUpperBoundDeps, ErrNotVerbose)
+		}
+		conflicts = g.Conflicts()
+	}
+	if p.DependencyConvergence {
+		for _, c := range conflicts {
+			ga := c.GroupId + ":" + c.ArtifactId
+			v := Violation{Rule: RuleDependencyConvergence, Artifact: ga}
+			versions := []string{}
+			for _, cv := range c.Versions {
+				versions = append(versions, cv.Version)
+				v.NodeIds = append(v.NodeIds, cv.NodeIds...)
+				v.Paths = append(v.Paths, cv.Paths...)
+			}
+			v.Message = fmt.Sprintf("dependency convergence error for %v, versions: %v", ga, strings.Join(versions, ", "))
+			violations = append(violations, v)
+		}
+	}
+	if p.RequireUpperBoundDeps {
+		for _, c := range conflicts {
+			violations = append(violations, g.upperBoundViolations(c, parents)...)
+		}
+	}
+
+	if len(p.BannedDependencies) > 0 {
+		patterns := []artifactPattern{}
+		for _, s := range p.BannedDependencies {
+			ap, err := parseArtifactPattern(s)
+			if err != nil {
+				return nil, err
+			}
+			patterns = append(patterns, ap)
+		}
+		for _, id := range g.resolvedNodes(parents) {
+			c := g.coords[id]
+			for _, ap := range patterns {
+				if ap.match(c) {
+					violations = append(violations, Violation{
+						Rule:     RuleBannedDependencies,
+						Artifact: c.Key(),
+						Message:  fmt.Sprintf("banned dependency %v found, matched by '%v'", c.Key(), ap.src),
+						NodeIds:  []int{id},
+						Paths:    g.paths(parents, id, maxConflictPaths),
+					})
+					break
+				}
+			}
+		}
+	}
+
+	if p.NoSnapshots {
+		release := map[int]bool{}
+		for _, m := range g.modules {
+			release[m] = !strings.HasSuffix(g.coords[m].Version, snapshotSuffix)
+		}
+		for _, id := range g.resolvedNodes(parents) {
+			c := g.coords[id]
+			if !strings.HasSuffix(c.Version, snapshotSuffix) || g.IsModule(id) {
+				continue
+			}
+			for _, m := range g.nodeModules[id] {
+				if !release[m] {
+					continue
+				}
+				violations = append(violations, Violation{
+					Rule:     RuleNoSnapshots,
+					Artifact: c.Key(),
+					Message:  fmt.Sprintf("release %v depends on snapshot %v", g.coords[m].Key(), c.Key()),
+					NodeIds:  []int{id},
+					Paths:    g.paths(parents, id, maxConflictPaths),
+				})
+				break
+			}
+		}
+	}
+
+	if p.MaxDepth > 0 {
+		depth := g.resolvedDepth()
+		for _, id := range g.resolvedNodes(parents) {
+			if d := depth[id]; d > p.MaxDepth {
+				c := g.coords[id]
+				violations = append(violations, Violation{
+					Rule:     RuleMaxDepth,
+					Artifact: c.Key(),
+					Message:  fmt.Sprintf("depth of %v is %d, exceeds max depth %d", c.Key(), d, p.MaxDepth),
+					NodeIds:  []int{id},
+					Paths:    g.paths(parents, id, maxConflictPaths),
+				})
+			}
+		}
+	}
+	return violations, nil
+}
+
+// Format violations as human-readable text.
+func FormatViolations(violations []Violation) string {
+	b := strings.Builder{}
+	for i, v := range violations {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(fmt.Sprintf("[%v] %v\n", v.Rule, v.Message))
+		for _, p := range v.Paths {
+			b.WriteString("    " + strings.Join(p, " -> ") + "\n")
+		}
+	}
+	return b.String()
+}
+
+// Format violations as JSON.
+func FormatViolationsJSON(violations []Violation) ([]byte, error) {
+	return json.MarshalIndent(violations, "", "  ")
+}
+
+// Incoming dependencies of each node, in the order of edges.
+func (g *MvnGraph) parentDependencies() map[int][]Dependency {
+	parents := map[int][]Dependency{}
+	for _, ed := range g.Edges() {
+		if d, ok := g.deps[[2]int{ed.FromId, ed.ToId}]; ok {
+			parents[d.ToId] = append(parents[d.ToId], d)
+		}
+	}
+	return parents
+}
+
+// Parent dependencies of each node following only the edges of the module.
+func (g *MvnGraph) moduleParents(parents map[int][]Dependency, module int) map[int][]Dependency {
+	mp := map[int][]Dependency{}
+	for id, ds := range parents {
+		for _, d := range ds {
+			if containsInt(g.edgeModules[[2]int{d.FromId, d.ToId}], module) {
+				mp[id] = append(mp[id], d)
+			}
+		}
+	}
+	return mp
+}
+
+// Violations of requireUpperBoundDeps for the conflict. Each version is compared with the version selected in the
+// modules that require it, at most one violation is reported for each required version.
+func (g *MvnGraph) upperBoundViolations(c Conflict, parents map[int][]Dependency) []Violation {
+	selected := map[int]string{}          // module -> selected version
+	required := map[int]map[string]bool{} // module -> required versions
+	for _, cv := range c.Versions {
+		for _, id := range cv.NodeIds {
+			if g.IsModule(id) {
+				selected[id] = cv.Version
+			}
+			for _, d := range parents[id] {
+				for _, m := range g.edgeModules[[2]int{d.FromId, d.ToId}] {
+					if required[m] == nil {
+						required[m] = map[string]bool{}
+					}
+					required[m][cv.Version] = true
+					if !d.Omitted() {
+						selected[m] = cv.Version
+					}
+				}
+			}
+		}
+	}
+
+	ga := c.GroupId + ":" + c.ArtifactId
+	violations := []Violation{}
+	for _, cv := range c.Versions {
+		lower := []string{}
+		met := map[string]bool{}
+		paths := [][]string{}
+		for _, m := range g.modules {
+			sel, ok := selected[m]
+			if !ok || !required[m][cv.Version] || version.Compare(cv.Version, sel) <= 0 {
+				continue
+			}
+			if !met[sel] {
+				met[sel] = true
+				lower = append(lower, sel)
+			}
+			mp := g.moduleParents(parents, m)
+			for _, id := range cv.NodeIds {
+				paths = append(paths, g.paths(mp, id, maxConflictPaths-len(paths))...)
+			}
+		}
+		if len(lower) == 0 {
+			continue
+		}
+		violations = append(violations, Violation{
+			Rule:     RuleRequireUpperBoundDeps,
+			Artifact: ga + ":" + cv.Version,
+			Message: fmt.Sprintf("require upper bound dependencies error for %v, %v is selected but %v is required", ga,
+				strings.Join(lower, ", "), cv.Version),
+			NodeIds: cv.NodeIds,
+			Paths:   paths,
+		})
+	}
+	return violations
+}
+
+// Nodes reached through resolved edges, i.e., nodes that are on the classpath, modules are excluded.
+func (g *MvnGraph) resolvedNodes(parents map[int][]Dependency) []int {
+	ids := []int{}
+	for _, n := range g.Nodes() {
+		for _, d := range parents[n.Id] {
+			if !d.Omitted() {
+				ids = append(ids, n.Id)
+				break
+			}
+		}
+	}
+	return ids
+}
+
+// Shortest depth of each node from the modules following resolved edges, modules are at depth 0.
+func (g *MvnGraph) resolvedDepth() map[int]int {
+	children := map[int][]int{}
+	for _, ed := range g.Edges() {
+		if d, ok := g.deps[[2]int{ed.FromId, ed.ToId}]; ok && !d.Omitted() {
+			children[d.FromId] = append(children[d.FromId], d.ToId)
+		}
+	}
+	depth := map[int]int{}
+	queue := []int{}
+	for _, m := range g.modules {
+		depth[m] = 0
+		queue = append(queue, m)
+	}
+	for len(queue) > 0 {
+		pop := queue[0]
+		queue = queue[1:]
+		for _, c := range children[pop] {
+			if _, ok := depth[c]; ok {
+				continue
+			}
+			depth[c] = depth[pop] + 1
+			queue = append(queue, c)
+		}
+	}
+	return depth
+}
+
+// groupId[:artifactId[:version]] pattern, missing parts match anything.
+type artifactPattern struct {
+	src   string
+	parts []*regexp.Regexp
+}
+
+func parseArtifactPattern(s string) (artifactPattern, error) {
+	ap := artifactPattern{src: s}
+	tkn := strings.Split(strings.TrimSpace(s), ":")
+	if len(tkn) > 3 || tkn[0] == "" {
+		return ap, fmt.Errorf("invalid artifact pattern '%v', should be groupId[:artifactId[:version]]", s)
+	}
+	for _, t := range tkn {
+		ap.parts = append(ap.parts, graph.GlobRegexp(t))
+	}
+	return ap, nil
+}
+
+func (ap artifactPattern) match(c Coordinate) bool {
+	fields := []string{c.GroupId, c.ArtifactId, c.Version}
+	for i, p := range ap.parts {
+		if !p.MatchString(fields[i]) {
+			return false
+		}
+	}
+	return true
+}
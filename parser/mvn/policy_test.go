@@ -0,0 +1,112 @@
+package mvn
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	p, err := LoadPolicy("../../testdata/policy/policy.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctn, err := os.ReadFile("../../testdata/verbose_mvn.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := ParseMvnGraph("dependency tree", string(ctn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	violations, err := p.Check(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("\n%v", FormatViolations(violations))
+
+	count := map[string]int{}
+	for _, v := range violations {
+		count[v.Rule]++
+	}
+	want := map[string]int{
+		RuleDependencyConvergence: 1, // jackson-databind
+		RuleBannedDependencies:    1, // commons-logging
+		RuleMaxDepth:              8,
+	}
+	for r, n := range want {
+		if count[r] != n {
+			t.Fatalf("should have %d violations of %v, %v", n, r, count)
+		}
+	}
+	if len(violations) != 10 {
+		t.Fatalf("unexpected violations, %v", count)
+	}
+
+	tree := `com.example:app:jar:1.0.0
++- com.example:client:jar:2.0.0-SNAPSHOT:compile
+|  \- (org.slf4j:slf4j-api:jar:1.7.30:compile - omitted for conflict with 1.7.9)
+\- org.slf4j:slf4j-api:jar:1.7.9:compile
+`
+	g, err = ParseMvnGraph("dependency tree", tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	violations, err = (&Policy{RequireUpperBoundDeps: true, NoSnapshots: true}).Check(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("\n%v", FormatViolations(violations))
+	if len(violations) != 2 || violations[0].Rule != RuleRequireUpperBoundDeps || violations[1].Rule != RuleNoSnapshots {
+		t.Fatalf("unexpected violations, %#v", violations)
+	}
+	if violations[0].Artifact != "org.slf4j:slf4j-api:1.7.30" {
+		t.Fatalf("1.7.30 should be required, %v", violations[0].Artifact)
+	}
+
+	if _, err := (&Policy{BannedDependencies: []string{"a:b:c:d"}}).Check(g); err == nil {
+		t.Fatal("invalid pattern should be rejected")
+	}
+
+	// without -Dverbose, conflicts are not available
+	g, err = ParseMvnGraph("dependency tree", "com.example:app:jar:1.0.0\n\\- org.slf4j:slf4j-api:jar:1.7.9:compile\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (&Policy{DependencyConvergence: true}).Check(g); !errors.Is(err, ErrNotVerbose) {
+		t.Fatalf("convergence should not be checked without -Dverbose, %v", err)
+	}
+	if _, err := (&Policy{NoSnapshots: true}).Check(g); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPolicyCheckReactor(t *testing.T) {
+	// a selects 1.7.9 and b selects 1.7.25, both require 1.7.30 through y, and b requires 1.7.20 through z
+	tree := `com.e:a:jar:1.0
++- org.slf4j:slf4j-api:jar:1.7.9:compile
+\- com.e:y:jar:1.0:compile
+   \- (org.slf4j:slf4j-api:jar:1.7.30:compile - omitted for conflict with 1.7.9)
+com.e:b:jar:1.0
++- org.slf4j:slf4j-api:jar:1.7.25:compile
++- com.e:y:jar:1.0:compile
+|  \- (org.slf4j:slf4j-api:jar:1.7.30:compile - omitted for conflict with 1.7.25)
+\- com.e:z:jar:1.0:compile
+   \- (org.slf4j:slf4j-api:jar:1.7.20:compile - omitted for conflict with 1.7.25)
+`
+	g, err := ParseMvnGraph("dependency tree", tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	violations, err := (&Policy{RequireUpperBoundDeps: true}).Check(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("\n%v", FormatViolations(violations))
+	if len(violations) != 1 || violations[0].Artifact != "org.slf4j:slf4j-api:1.7.30" {
+		t.Fatalf("only 1.7.30 should be reported once, %#v", violations)
+	}
+	if len(violations[0].Paths) != 2 || violations[0].Paths[0][0] != "com.e:a:jar:1.0" || violations[0].Paths[1][0] != "com.e:b:jar:1.0" {
+		t.Fatalf("should have one path in each module, %v", violations[0].Paths)
+	}
+}
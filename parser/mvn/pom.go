@@ -0,0 +1,658 @@
+package mvn
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/curtisnewbie/grapher/parser/mvn/version"
+)
+
+const (
+	pomFile        = "pom.xml"
+	defaultPomPath = "../pom.xml"
+	maxInterpolate = 10
+)
+
+var propertyRegex = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// Project model read from pom.xml.
+//
+// Only the parts that are relevant to dependency resolution are read, profiles and plugins are ignored.
+type Pom struct {
+	Path                 string // path of the pom file, empty if it's not read from a file
+	Parent               *PomParent
+	GroupId              string
+	ArtifactId           string
+	Version              string
+	Packaging            string
+	Properties           map[string]string
+	Dependencies         []PomDependency
+	DependencyManagement []PomDependency
+	Modules              []string // relative paths of modules
+}
+
+// Parent of a Pom.
+type PomParent struct {
+	GroupId      string
+	ArtifactId   string
+	Version      string
+	RelativePath string // '../pom.xml' by default, empty if the parent is only looked up in the repository
+}
+
+// Dependency declared in a Pom, either in dependencies or dependencyManagement.
+type PomDependency struct {
+	GroupId    string
+	ArtifactId string
+	Version    string
+	Type       string
+	Classifier string
+	Scope      string
+	Optional   bool
+	Exclusions []Exclusion
+}
+
+// Exclusion of a PomDependency, '*' matches any groupId or artifactId.
+type Exclusion struct {
+	GroupId    string `json:"groupId"`
+	ArtifactId string `json:"artifactId"`
+}
+
+// Whether the exclusion matches the coordinate.
+func (e Exclusion) Excludes(c Coordinate) bool {
+	return (e.GroupId == "*" || e.GroupId == c.GroupId) && (e.ArtifactId == "*" || e.ArtifactId == c.ArtifactId)
+}
+
+// Coordinate of the project.
+func (p *Pom) Coordinate() Coordinate {
+	pkg := p.Packaging
+	if pkg == "" {
+		pkg = "jar"
+	}
+	return Coordinate{GroupId: p.GroupId, ArtifactId: p.ArtifactId, Packaging: pkg, Version: p.Version}
+}
+
+// Coordinate of the dependency, type and scope are defaulted to jar and compile.
+func (d PomDependency) Coordinate() Coordinate {
+	typ := d.Type
+	if typ == "" {
+		typ = "jar"
+	}
+	scope := d.Scope
+	if scope == "" {
+		scope = ScopeCompile
+	}
+	return Coordinate{
+		GroupId:    d.GroupId,
+		ArtifactId: d.ArtifactId,
+		Packaging:  typ,
+		Classifier: d.Classifier,
+		Version:    d.Version,
+		Scope:      scope,
+		Optional:   d.Optional,
+	}
+}
+
+// Key of the dependency in dependencyManagement, i.e., groupId:artifactId:type[:classifier].
+func (d PomDependency) managementKey() string {
+	typ := d.Type
+	if typ == "" {
+		typ = "jar"
+	}
+	k := d.GroupId + ":" + d.ArtifactId + ":" + typ
+	if d.Classifier != "" {
+		k += ":" + d.Classifier
+	}
+	return k
+}
+
+type pomXml struct {
+	Parent *struct {
+		GroupId      string  `xml:"groupId"`
+		ArtifactId   string  `xml:"artifactId"`
+		Version      string  `xml:"version"`
+		RelativePath *string `xml:"relativePath"`
+	} `xml:"parent"`
+	GroupId    string `xml:"groupId"`
+	ArtifactId string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Packaging  string `xml:"packaging"`
+	Properties struct {
+		Props []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:",any"`
+	} `xml:"properties"`
+	Dependencies         []pomDependencyXml `xml:"dependencies>dependency"`
+	DependencyManagement []pomDependencyXml `xml:"dependencyManagement>dependencies>dependency"`
+	Modules              []string           `xml:"modules>module"`
+}
+
+type pomDependencyXml struct {
+	GroupId    string `xml:"groupId"`
+	ArtifactId string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Type       string `xml:"type"`
+	Classifier string `xml:"classifier"`
+	Scope      string `xml:"scope"`
+	Optional   string `xml:"optional"`
+	Exclusions []struct {
+		GroupId    string `xml:"groupId"`
+		ArtifactId string `xml:"artifactId"`
+	} `xml:"exclusions>exclusion"`
+}
+
+func (d pomDependencyXml) dependency() PomDependency {
+	pd := PomDependency{
+		GroupId:    strings.TrimSpace(d.GroupId),
+		ArtifactId: strings.TrimSpace(d.ArtifactId),
+		Version:    strings.TrimSpace(d.Version),
+		Type:       strings.TrimSpace(d.Type),
+		Classifier: strings.TrimSpace(d.Classifier),
+		Scope:      strings.TrimSpace(d.Scope),
+		Optional:   strings.TrimSpace(d.Optional) == "true",
+	}
+	for _, e := range d.Exclusions {
+		pd.Exclusions = append(pd.Exclusions, Exclusion{GroupId: strings.TrimSpace(e.GroupId), ArtifactId: strings.TrimSpace(e.ArtifactId)})
+	}
+	return pd
+}
+
+// Read pom.xml as is, parent, properties and dependencyManagement are not applied.
+//
+// If path is a directory, the pom.xml in the directory is read.
+func ReadPom(path string) (*Pom, error) {
+	if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+		path = filepath.Join(path, pomFile)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w, %v", ErrPomNotFound, path)
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var px pomXml
+	if err := xml.NewDecoder(f).Decode(&px); err != nil {
+		return nil, fmt.Errorf("failed to parse %v, %w", path, err)
+	}
+	p := &Pom{
+		Path:       path,
+		GroupId:    strings.TrimSpace(px.GroupId),
+		ArtifactId: strings.TrimSpace(px.ArtifactId),
+		Version:    strings.TrimSpace(px.Version),
+		Packaging:  strings.TrimSpace(px.Packaging),
+		Properties: map[string]string{},
+	}
+	if px.Parent != nil {
+		p.Parent = &PomParent{
+			GroupId:      strings.TrimSpace(px.Parent.GroupId),
+			ArtifactId:   strings.TrimSpace(px.Parent.ArtifactId),
+			Version:      strings.TrimSpace(px.Parent.Version),
+			RelativePath: defaultPomPath,
+		}
+		if px.Parent.RelativePath != nil {
+			p.Parent.RelativePath = strings.TrimSpace(*px.Parent.RelativePath)
+		}
+	}
+	for _, v := range px.Properties.Props {
+		p.Properties[v.XMLName.Local] = strings.TrimSpace(v.Value)
+	}
+	for _, d := range px.Dependencies {
+		p.Dependencies = append(p.Dependencies, d.dependency())
+	}
+	for _, d := range px.DependencyManagement {
+		p.DependencyManagement = append(p.DependencyManagement, d.dependency())
+	}
+	for _, m := range px.Modules {
+		p.Modules = append(p.Modules, strings.TrimSpace(m))
+	}
+	return p, nil
+}
+
+// Path of the artifact in local repository, ext is the file extension, e.g., pom, jar.
+func repoPath(repo string, c Coordinate, ext string) string {
+	name := c.ArtifactId + "-" + c.Version
+	if c.Classifier != "" {
+		name += "-" + c.Classifier
+	}
+	return filepath.Join(repoDir(repo, c.GroupId, c.ArtifactId), c.Version, name+"."+ext)
+}
+
+// Directory of the artifact in local repository, each version is a sub directory.
+func repoDir(repo string, groupId string, artifactId string) string {
+	return filepath.Join(repo, filepath.FromSlash(strings.ReplaceAll(groupId, ".", "/")), artifactId)
+}
+
+// Loader of poms, poms are looked up in the reactor (i.e., the project being parsed and its modules) first and then
+// the local repository.
+type pomLoader struct {
+	repo      string
+	files     map[string]*Pom // path -> raw pom
+	reactor   map[string]*Pom // groupId:artifactId:version -> raw pom in reactor
+	modules   []*Pom          // raw poms in reactor, in the order they are found
+	effective map[*Pom]*Pom   // raw pom -> effective pom
+	loading   map[*Pom]bool   // poms being loaded, to detect cycles
+	verbose   bool
+	pins      map[string]string // groupId:artifactId -> version, see PomParam.Versions
+}
+
+func newPomLoader(p PomParam) *pomLoader {
+	return &pomLoader{
+		repo:      p.Repo,
+		verbose:   p.Verbose,
+		pins:      p.Versions,
+		files:     map[string]*Pom{},
+		reactor:   map[string]*Pom{},
+		effective: map[*Pom]*Pom{},
+		loading:   map[*Pom]bool{},
+	}
+}
+
+func (l *pomLoader) readFile(path string) (*Pom, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if p, ok := l.files[abs]; ok {
+		return p, nil
+	}
+	p, err := ReadPom(abs)
+	if err != nil {
+		return nil, err
+	}
+	l.files[abs] = p
+	l.files[p.Path] = p
+	return p, nil
+}
+
+// Load pom and its modules recursively into the reactor.
+func (l *pomLoader) loadReactor(path string) (*Pom, error) {
+	p, err := l.collectModules(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// versions of modules may be inherited from parents or defined by properties, e.g., ${revision}
+	for _, m := range l.modules {
+		e, err := l.inherit(m)
+		if err != nil {
+			continue
+		}
+		v := interpolate(e.Version, e.Properties)
+		l.reactor[interpolate(e.GroupId, e.Properties)+":"+e.ArtifactId+":"+v] = m
+	}
+	return p, nil
+}
+
+func (l *pomLoader) collectModules(path string) (*Pom, error) {
+	p, err := l.readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range l.modules {
+		if m == p {
+			return p, nil
+		}
+	}
+	l.modules = append(l.modules, p)
+
+	g, a, v := p.GroupId, p.ArtifactId, p.Version
+	if p.Parent != nil {
+		if g == "" {
+			g = p.Parent.GroupId
+		}
+		if v == "" {
+			v = p.Parent.Version
+		}
+	}
+	v = interpolate(v, p.Properties)
+	l.reactor[g+":"+a+":"+v] = p
+
+	for _, m := range p.Modules {
+		mp := filepath.Join(filepath.Dir(p.Path), filepath.FromSlash(m))
+		if _, err := l.collectModules(mp); err != nil {
+			return nil, fmt.Errorf("failed to load module '%v' of %v, %w", m, p.Path, err)
+		}
+	}
+	return p, nil
+}
+
+// Find raw pom by coordinate in the reactor or the local repository.
+//
+// If the version is a range, e.g., [1.0,2.0), the highest version available within the range is used.
+func (l *pomLoader) find(groupId string, artifactId string, v string) (*Pom, error) {
+	if pv, ok := l.pins[groupId+":"+artifactId]; ok {
+		v = pv
+	}
+	v, err := l.resolveVersion(groupId, artifactId, v)
+	if err != nil {
+		return nil, err
+	}
+	if p, ok := l.reactor[groupId+":"+artifactId+":"+v]; ok {
+		return p, nil
+	}
+	if l.repo == "" {
+		return nil, fmt.Errorf("%w, %v:%v:%v, local repository is not specified", ErrPomNotFound, groupId, artifactId, v)
+	}
+	return l.readFile(repoPath(l.repo, Coordinate{GroupId: groupId, ArtifactId: artifactId, Version: v}, "pom"))
+}
+
+// Resolve version range to the highest version available in the reactor or the local repository, plain versions are
+// returned as is.
+func (l *pomLoader) resolveVersion(groupId string, artifactId string, v string) (string, error) {
+	if !version.IsRange(v) {
+		return v, nil
+	}
+	r, err := version.ParseRange(v)
+	if err != nil {
+		return "", fmt.Errorf("invalid version of %v:%v, %w", groupId, artifactId, err)
+	}
+	available := []string{}
+	prefix := groupId + ":" + artifactId + ":"
+	for k := range l.reactor {
+		if rv, ok := strings.CutPrefix(k, prefix); ok {
+			available = append(available, rv)
+		}
+	}
+	if l.repo != "" {
+		if entries, err := os.ReadDir(repoDir(l.repo, groupId, artifactId)); err == nil {
+			for _, e := range entries {
+				if e.IsDir() {
+					available = append(available, e.Name())
+				}
+			}
+		}
+	}
+	if s, ok := r.Select(available); ok {
+		return s, nil
+	}
+	return "", fmt.Errorf("%w, no version of %v:%v within %v", ErrPomNotFound, groupId, artifactId, v)
+}
+
+// Find raw pom of the parent, relativePath is checked before looking up the reactor and the local repository.
+func (l *pomLoader) parent(p *Pom) (*Pom, error) {
+	pp := p.Parent
+	if pp.RelativePath != "" && p.Path != "" {
+		path := filepath.Join(filepath.Dir(p.Path), filepath.FromSlash(pp.RelativePath))
+		if rp, err := l.readFile(path); err == nil && rp.ArtifactId == pp.ArtifactId {
+			return rp, nil
+		}
+	}
+	rp, err := l.find(pp.GroupId, pp.ArtifactId, pp.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find parent of %v, %w", p.Path, err)
+	}
+	return rp, nil
+}
+
+// Merge pom with its parents, properties are not interpolated yet.
+func (l *pomLoader) inherit(p *Pom) (*Pom, error) {
+	if l.loading[p] {
+		return nil, fmt.Errorf("cyclic parent or import detected at %v", p.Path)
+	}
+	l.loading[p] = true
+	defer delete(l.loading, p)
+
+	m := &Pom{
+		Path:                 p.Path,
+		Parent:               p.Parent,
+		GroupId:              p.GroupId,
+		ArtifactId:           p.ArtifactId,
+		Version:              p.Version,
+		Packaging:            p.Packaging,
+		Properties:           map[string]string{},
+		Dependencies:         []PomDependency{},
+		DependencyManagement: []PomDependency{},
+		Modules:              p.Modules,
+	}
+	if p.Parent != nil {
+		rp, err := l.parent(p)
+		if err != nil {
+			return nil, err
+		}
+		pm, err := l.inherit(rp)
+		if err != nil {
+			return nil, err
+		}
+		if m.GroupId == "" {
+			m.GroupId = p.Parent.GroupId
+		}
+		if m.Version == "" {
+			m.Version = p.Parent.Version
+		}
+		for k, v := range pm.Properties {
+			m.Properties[k] = v
+		}
+		m.Properties["project.parent.groupId"] = p.Parent.GroupId
+		m.Properties["project.parent.artifactId"] = p.Parent.ArtifactId
+		m.Properties["project.parent.version"] = p.Parent.Version
+		m.Dependencies = mergeDependencies(pm.Dependencies, p.Dependencies)
+		m.DependencyManagement = mergeDependencies(pm.DependencyManagement, p.DependencyManagement)
+	} else {
+		m.Dependencies = append(m.Dependencies, p.Dependencies...)
+		m.DependencyManagement = append(m.DependencyManagement, p.DependencyManagement...)
+	}
+	for k, v := range p.Properties {
+		m.Properties[k] = v
+	}
+	return m, nil
+}
+
+// Merge dependencies declared in parent and child, the ones declared in child take precedence.
+func mergeDependencies(parent []PomDependency, child []PomDependency) []PomDependency {
+	declared := map[string]struct{}{}
+	for _, d := range child {
+		declared[d.managementKey()] = struct{}{}
+	}
+	merged := make([]PomDependency, 0, len(parent)+len(child))
+	for _, d := range parent {
+		if _, ok := declared[d.managementKey()]; !ok {
+			merged = append(merged, d)
+		}
+	}
+	return append(merged, child...)
+}
+
+// Build effective pom, i.e., parents are merged, properties are interpolated, BOMs are imported and
+// dependencyManagement is applied to the dependencies.
+func (l *pomLoader) load(p *Pom) (*Pom, error) {
+	if e, ok := l.effective[p]; ok {
+		return e, nil
+	}
+	e, err := l.inherit(p)
+	if err != nil {
+		return nil, err
+	}
+	l.loading[p] = true
+	defer delete(l.loading, p)
+
+	// interpolate
+	props := map[string]string{}
+	for k, v := range e.Properties {
+		props[k] = v
+	}
+	for _, pre := range []string{"project.", "pom.", ""} {
+		props[pre+"groupId"] = e.GroupId
+		props[pre+"artifactId"] = e.ArtifactId
+		props[pre+"version"] = e.Version
+	}
+	if e.Path != "" {
+		props["project.basedir"] = filepath.Dir(e.Path)
+		props["basedir"] = filepath.Dir(e.Path)
+	}
+	e.GroupId = interpolate(e.GroupId, props)
+	e.Version = interpolate(e.Version, props)
+	props["project.groupId"], props["project.version"] = e.GroupId, e.Version
+	for k, v := range e.Properties {
+		e.Properties[k] = interpolate(v, props)
+	}
+	interpolateDependencies(e.Dependencies, props)
+	interpolateDependencies(e.DependencyManagement, props)
+
+	// import BOMs, entries declared before take precedence
+	managed := []PomDependency{}
+	declared := map[string]struct{}{}
+	imports := []PomDependency{}
+	for _, d := range e.DependencyManagement {
+		if d.Scope == ScopeImport && d.Type == "pom" {
+			imports = append(imports, d)
+			continue
+		}
+		if _, ok := declared[d.managementKey()]; !ok {
+			declared[d.managementKey()] = struct{}{}
+			managed = append(managed, d)
+		}
+	}
+	for _, d := range imports {
+		rb, err := l.find(d.GroupId, d.ArtifactId, d.Version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import %v:%v:%v in %v, %w", d.GroupId, d.ArtifactId, d.Version, p.Path, err)
+		}
+		bom, err := l.load(rb)
+		if err != nil {
+			return nil, err
+		}
+		for _, bd := range bom.DependencyManagement {
+			if _, ok := declared[bd.managementKey()]; !ok {
+				declared[bd.managementKey()] = struct{}{}
+				managed = append(managed, bd)
+			}
+		}
+	}
+	e.DependencyManagement = managed
+	e.Dependencies = manageDependencies(e.Dependencies, managed)
+	for _, d := range e.Dependencies {
+		if d.Version == "" {
+			return nil, fmt.Errorf("version of %v:%v is missing in %v", d.GroupId, d.ArtifactId, p.Path)
+		}
+	}
+
+	l.effective[p] = e
+	return e, nil
+}
+
+// Apply dependencyManagement to dependencies that don't declare version, scope or exclusions.
+func manageDependencies(deps []PomDependency, managed []PomDependency) []PomDependency {
+	mm := map[string]PomDependency{}
+	for _, d := range managed {
+		mm[d.managementKey()] = d
+	}
+	out := make([]PomDependency, 0, len(deps))
+	for _, d := range deps {
+		if m, ok := mm[d.managementKey()]; ok {
+			if d.Version == "" {
+				d.Version = m.Version
+			}
+			if d.Scope == "" {
+				d.Scope = m.Scope
+			}
+			if len(d.Exclusions) < 1 {
+				d.Exclusions = m.Exclusions
+			}
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+func interpolateDependencies(deps []PomDependency, props map[string]string) {
+	for i := range deps {
+		d := &deps[i]
+		d.GroupId = interpolate(d.GroupId, props)
+		d.ArtifactId = interpolate(d.ArtifactId, props)
+		d.Version = interpolate(d.Version, props)
+		d.Type = interpolate(d.Type, props)
+		d.Classifier = interpolate(d.Classifier, props)
+		d.Scope = interpolate(d.Scope, props)
+	}
+}
+
+// Replace ${...} with property values, unknown properties are left as is.
+func interpolate(s string, props map[string]string) string {
+	for i := 0; i < maxInterpolate && strings.Contains(s, "${"); i++ {
+		r := propertyRegex.ReplaceAllStringFunc(s, func(m string) string {
+			if v, ok := props[m[2:len(m)-1]]; ok {
+				return v
+			}
+			return m
+		})
+		if r == s {
+			break
+		}
+		s = r
+	}
+	return s
+}
+
+// Parameters of ParsePom.
+type PomParam struct {
+	Repo     string            // local repository directory (e.g., ~/.m2/repository), for parents and BOMs that are not in the reactor
+	Verbose  bool              // include dependencies omitted for duplicate or conflict, like mvn dependency:tree -Dverbose, only used by ResolvePom
+	Versions map[string]string // groupId:artifactId -> version, overrides versions of the artifacts wherever they are referenced, including parents and BOMs
+}
+
+// Read effective pom, parents are merged, properties are interpolated, BOMs are imported and dependencyManagement is
+// applied.
+//
+// If path is a directory, the pom.xml in the directory is read.
+func ReadEffectivePom(path string, p PomParam) (*Pom, error) {
+	l := newPomLoader(p)
+	rp, err := l.readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return l.load(rp)
+}
+
+// Parse pom.xml without running mvn, only dependencies declared in the project and its modules are included.
+//
+// Each module is a separate tree like the output of mvn dependency:tree in a multi-module project. Parents and
+// imported BOMs are looked up in the reactor and then the local repository, if any of them is not found,
+// ErrPomNotFound is returned.
+func ParsePom(title string, path string, p PomParam) (*MvnGraph, error) {
+	l := newPomLoader(p)
+	if _, err := l.loadReactor(path); err != nil {
+		return nil, err
+	}
+	b := newTreeBuilder()
+	for _, m := range l.modules {
+		e, err := l.load(m)
+		if err != nil {
+			return nil, err
+		}
+		r := b.addRoot(e.Coordinate())
+		for _, d := range e.Dependencies {
+			c := d.Coordinate()
+			b.connect(r, b.add(c, 1), Dependency{Kind: EdgeResolved, Scope: c.Scope})
+		}
+	}
+	return l.build(b, title)
+}
+
+// Build graph with the pom of each module recorded, paths are relative to the working directory if possible.
+func (l *pomLoader) build(b *treeBuilder, title string) (*MvnGraph, error) {
+	g, err := b.build(title)
+	if err != nil {
+		return nil, err
+	}
+	g.verbose = l.verbose
+	wd, _ := os.Getwd()
+	for _, m := range l.modules {
+		if e, ok := l.effective[m]; ok {
+			path := m.Path
+			if rel, err := filepath.Rel(wd, path); err == nil {
+				path = rel
+			}
+			g.modulePoms[e.Coordinate().Key()] = path
+		}
+	}
+	return g, nil
+}
@@ -0,0 +1,136 @@
+package mvn
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestParsePom(t *testing.T) {
+	g, err := ParsePom("shop", "../../testdata/pom/shop", PomParam{Repo: "../../testdata/m2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := g.SDraw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(s)
+
+	if m := g.Modules(); len(m) != 3 {
+		t.Fatalf("should have 3 modules, %v", m)
+	}
+	expected := map[string]string{
+		"com.example.shop:shop-parent:pom:1.0.0":                         "",
+		"com.example.shop:shop-common:jar:1.0.0":                         "",
+		"com.example.shop:shop-service:jar:1.0.0":                        "",
+		"junit:junit:jar:4.13.2:test":                                    "inherited from corp-parent",
+		"org.slf4j:slf4j-api:jar:1.7.36:compile":                         "managed by corp-parent, takes precedence over the BOM",
+		"com.google.guava:guava:jar:32.1.2-jre:compile":                  "imported from platform-bom",
+		"com.fasterxml.jackson.core:jackson-databind:jar:2.15.2:compile": "imported from platform-bom",
+		"org.apache.commons:commons-lang3:jar:3.12.0:compile (optional)": "property",
+		"com.example:legacy-client:jar:1.0:compile":                      "",
+	}
+	found := map[string]bool{}
+	for _, n := range g.Nodes() {
+		c, _ := g.Coordinate(n.Id)
+		if _, ok := expected[c.String()]; !ok {
+			t.Fatalf("unexpected node %v", c)
+		}
+		found[c.String()] = true
+	}
+	for k, v := range expected {
+		if !found[k] {
+			t.Fatalf("%v (%v) not found", k, v)
+		}
+	}
+
+	common := g.FindArtifact("com.example.shop", "shop-common")[0].Id
+	service := g.FindArtifact("com.example.shop", "shop-service")[0].Id
+	if d, ok := g.Dependency(service, common); !ok || !d.InterModule {
+		t.Fatalf("shop-service should depend on shop-common, %#v", d)
+	}
+
+	if _, err := ParsePom("shop", "../../testdata/pom/shop", PomParam{}); !errors.Is(err, ErrPomNotFound) {
+		t.Fatalf("should return ErrPomNotFound without local repository, %v", err)
+	}
+}
+
+func TestReadEffectivePom(t *testing.T) {
+	p, err := ReadEffectivePom("../../testdata/pom/shop/shop-service/pom.xml", PomParam{Repo: "../../testdata/m2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.GroupId != "com.example.shop" || p.Version != "1.0.0" {
+		t.Fatalf("groupId and version should be inherited, %v", p.Coordinate())
+	}
+	if v := p.Properties["slf4j.version"]; v != "1.7.36" {
+		t.Fatalf("properties should be inherited, %v", v)
+	}
+	if len(p.Dependencies) != 5 {
+		t.Fatalf("should have 5 dependencies, %#v", p.Dependencies)
+	}
+	for _, d := range p.DependencyManagement {
+		if d.Scope == ScopeImport {
+			t.Fatalf("BOM should be imported, %#v", d)
+		}
+	}
+}
+
+func TestResolvePom(t *testing.T) {
+	g, err := ResolvePom("shop", "../../testdata/pom/shop", PomParam{Repo: "../../testdata/m2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := g.SDraw()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctn, err := os.ReadFile("../../testdata/pom/shop_mvn.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mg, err := ParseMvnGraph("shop", string(ctn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := mg.SDraw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual != expected {
+		t.Fatalf("resolved graph is different from mvn dependency:tree, expected:\n%v\nactual:\n%v", expected, actual)
+	}
+
+	if _, err := ResolvePom("shop", "../../testdata/pom/shop", PomParam{Repo: t.TempDir()}); !errors.Is(err, ErrPomNotFound) {
+		t.Fatalf("should return ErrPomNotFound, %v", err)
+	}
+}
+
+func TestResolveVersionRange(t *testing.T) {
+	l := newPomLoader(PomParam{Repo: "../../testdata/m2"})
+	cases := map[string]string{
+		"1.11":          "1.11",
+		"[1.0,2.0)":     "1.15",
+		"[1.0,1.15)":    "1.11",
+		"(,1.11]":       "1.11",
+		"[1.11],[1.15]": "1.15",
+	}
+	for spec, want := range cases {
+		v, err := l.resolveVersion("commons-codec", "commons-codec", spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != want {
+			t.Fatalf("%v should be resolved to %v, %v", spec, want, v)
+		}
+	}
+	if _, err := l.resolveVersion("commons-codec", "commons-codec", "[2.0,)"); !errors.Is(err, ErrPomNotFound) {
+		t.Fatalf("should return ErrPomNotFound, %v", err)
+	}
+	p, err := l.find("commons-codec", "commons-codec", "[1.0,1.15)")
+	if err != nil || p.Version != "1.11" {
+		t.Fatalf("should find 1.11, %v", err)
+	}
+}
@@ -0,0 +1,339 @@
+package mvn
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	RuleVersionConflict = "versionConflict"
+
+	defaultPom   = "pom.xml"
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	toolName     = "mtree"
+	toolUri      = "https://github.com/curtisnewbie/grapher"
+)
+
+var ruleDescriptions = map[string]string{
+	RuleVersionConflict:       "Artifact appears with multiple versions",
+	RuleDependencyConvergence: "All versions of the same artifact must converge",
+	RuleRequireUpperBoundDeps: "Selected version must not be lower than any requested version",
+	RuleBannedDependencies:    "Banned dependencies must not be used",
+	RuleNoSnapshots:           "Release modules must not depend on snapshots",
+	RuleMaxDepth:              "Depth of transitive dependencies must not exceed the limit",
+}
+
+// Parameters of FormatSARIF and FormatJUnit.
+type ReportParam struct {
+	Pom   string            // pom.xml that findings point to, 'pom.xml' by default
+	Poms  map[string]string // pom.xml of each module keyed by Coordinate.Key() of the module, see MvnGraph.ModulePoms
+	Rules []string          // rules that are checked, rules without violations are reported as passed in JUnit XML
+}
+
+// locate pom.xml and the line of the direct dependency on the path.
+func (p ReportParam) locate(v Violation) (string, int) {
+	pom := p.Pom
+	if pom == "" {
+		pom = defaultPom
+	}
+	if len(v.Paths) < 1 || len(v.Paths[0]) < 1 {
+		return pom, 0
+	}
+	path := v.Paths[0]
+	if mp, ok := p.Poms[path[0]]; ok {
+		pom = mp
+	}
+	if len(path) < 2 {
+		return pom, 0
+	}
+	c, err := ParseCoordinate(path[1])
+	if err != nil {
+		return pom, 0
+	}
+	return pom, findDependencyLine(pom, c)
+}
+
+// Find line of the dependency declaration in pom.xml, return 0 if not found. The line of <artifactId> is returned,
+// <groupId> must match as well if it's present and not interpolated from properties.
+//
+// Only <dependencies> of the project or its profiles are searched, the ones in <dependencyManagement> and the ones of
+// plugins are skipped.
+func findDependencyLine(pom string, c Coordinate) int {
+	b, err := os.ReadFile(pom)
+	if err != nil {
+		return 0
+	}
+	d := xml.NewDecoder(bytes.NewReader(b))
+	d.Strict = false
+	stack := []string{}
+	skip := 0 // depth of <dependencyManagement> or <plugin> being skipped
+	var start int64
+	var groupId, artifactId string
+	line := 0
+	inDependency := func() bool {
+		n := len(stack)
+		return skip == 0 && n >= 3 && stack[n-2] == "dependency" && stack[n-3] == "dependencies"
+	}
+	for {
+		tk, err := d.Token()
+		if err != nil {
+			return 0
+		}
+		switch t := tk.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name.Local)
+			if skip == 0 && (t.Name.Local == "dependencyManagement" || t.Name.Local == "plugin") {
+				skip = len(stack)
+			}
+			if t.Name.Local == "dependency" {
+				groupId, artifactId, line = "", "", 0
+			}
+			start = d.InputOffset()
+		case xml.EndElement:
+			n := len(stack)
+			if skip == 0 && n >= 2 && stack[n-1] == "dependency" && stack[n-2] == "dependencies" && artifactId == c.ArtifactId &&
+				(groupId == "" || strings.Contains(groupId, "${") || groupId == c.GroupId) {
+				return line
+			}
+			if skip == n {
+				skip = 0
+			}
+			if n > 0 {
+				stack = stack[:n-1]
+			}
+		case xml.CharData:
+			if !inDependency() {
+				continue
+			}
+			switch stack[len(stack)-1] {
+			case "groupId":
+				groupId = strings.TrimSpace(string(t))
+			case "artifactId":
+				artifactId = strings.TrimSpace(string(t))
+				line = bytes.Count(b[:start], []byte("\n")) + 1
+			}
+		}
+	}
+}
+
+// Convert version conflicts to violations of RuleVersionConflict.
+func ConflictViolations(conflicts []Conflict) []Violation {
+	violations := make([]Violation, 0, len(conflicts))
+	for _, c := range conflicts {
+		ga := c.GroupId + ":" + c.ArtifactId
+		v := Violation{Rule: RuleVersionConflict, Artifact: ga}
+		versions := []string{}
+		for _, cv := range c.Versions {
+			versions = append(versions, cv.Version)
+			v.NodeIds = append(v.NodeIds, cv.NodeIds...)
+			v.Paths = append(v.Paths, cv.Paths...)
+		}
+		v.Message = fmt.Sprintf("%v has multiple versions: %v, selected: %v", ga, strings.Join(versions, ", "), strings.Join(c.Selected, ", "))
+		violations = append(violations, v)
+	}
+	return violations
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool struct {
+		Driver struct {
+			Name           string      `json:"name"`
+			InformationUri string      `json:"informationUri"`
+			Rules          []sarifRule `json:"rules"`
+		} `json:"driver"`
+	} `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifRule struct {
+	Id               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleId    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+	CodeFlows []sarifCodeFlow `json:"codeFlows,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	Message          *sarifMessage          `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation struct {
+		Uri string `json:"uri"`
+	} `json:"artifactLocation"`
+	Region *sarifRegion `json:"region,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifCodeFlow struct {
+	ThreadFlows []sarifThreadFlow `json:"threadFlows"`
+}
+
+type sarifThreadFlow struct {
+	Locations []sarifThreadFlowLocation `json:"locations"`
+}
+
+type sarifThreadFlowLocation struct {
+	Location sarifLocation `json:"location"`
+}
+
+// Format violations as SARIF 2.1.0 log, each result points to pom.xml, and each dependency path is included as a
+// code flow.
+func FormatSARIF(violations []Violation, p ReportParam) ([]byte, error) {
+	run := sarifRun{Results: []sarifResult{}}
+	run.Tool.Driver.Name = toolName
+	run.Tool.Driver.InformationUri = toolUri
+	run.Tool.Driver.Rules = []sarifRule{}
+
+	rules := map[string]struct{}{}
+	addRule := func(r string) {
+		if _, ok := rules[r]; ok {
+			return
+		}
+		rules[r] = struct{}{}
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{Id: r, ShortDescription: sarifMessage{Text: ruleDescriptions[r]}})
+	}
+	for _, r := range p.Rules {
+		addRule(r)
+	}
+
+	for _, v := range violations {
+		addRule(v.Rule)
+		level := "error"
+		if v.Rule == RuleVersionConflict {
+			level = "warning"
+		}
+		pom, line := p.locate(v)
+		loc := &sarifPhysicalLocation{}
+		loc.ArtifactLocation.Uri = filepath.ToSlash(pom)
+		if line > 0 {
+			loc.Region = &sarifRegion{StartLine: line}
+		}
+		res := sarifResult{
+			RuleId:    v.Rule,
+			Level:     level,
+			Message:   sarifMessage{Text: v.Message},
+			Locations: []sarifLocation{{PhysicalLocation: loc}},
+		}
+		for _, path := range v.Paths {
+			tf := sarifThreadFlow{}
+			for _, c := range path {
+				tf.Locations = append(tf.Locations, sarifThreadFlowLocation{Location: sarifLocation{Message: &sarifMessage{Text: c}}})
+			}
+			res.CodeFlows = append(res.CodeFlows, sarifCodeFlow{ThreadFlows: []sarifThreadFlow{tf}})
+		}
+		run.Results = append(run.Results, res)
+	}
+	return json.MarshalIndent(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}}, "", "  ")
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Line      int           `xml:"line,attr,omitempty"`
+	Failure   *junitFailure `xml:"failure"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// Format violations as JUnit XML, each rule is a test suite and each violation is a failed test case with the
+// dependency paths included, rules without violations are reported as passed test cases.
+func FormatJUnit(violations []Violation, p ReportParam) ([]byte, error) {
+	doc := junitTestSuites{Name: toolName}
+	suites := map[string]int{}
+	suite := func(r string) *junitTestSuite {
+		i, ok := suites[r]
+		if !ok {
+			i = len(doc.Suites)
+			suites[r] = i
+			doc.Suites = append(doc.Suites, junitTestSuite{Name: r})
+		}
+		return &doc.Suites[i]
+	}
+	for _, r := range p.Rules {
+		suite(r)
+	}
+
+	for _, v := range violations {
+		s := suite(v.Rule)
+		pom, line := p.locate(v)
+		b := strings.Builder{}
+		for _, path := range v.Paths {
+			b.WriteString(strings.Join(path, " -> ") + "\n")
+		}
+		s.Cases = append(s.Cases, junitTestCase{
+			Name:      v.Artifact,
+			ClassName: pom,
+			File:      pom,
+			Line:      line,
+			Failure:   &junitFailure{Message: v.Message, Type: v.Rule, Text: b.String()},
+		})
+		s.Tests++
+		s.Failures++
+	}
+	pom, _ := p.locate(Violation{})
+	for i := range doc.Suites {
+		s := &doc.Suites[i]
+		if s.Tests < 1 {
+			s.Cases = append(s.Cases, junitTestCase{Name: s.Name, ClassName: pom})
+			s.Tests++
+		}
+		doc.Tests += s.Tests
+		doc.Failures += s.Failures
+	}
+
+	buf := bytes.Buffer{}
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
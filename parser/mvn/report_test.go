@@ -0,0 +1,127 @@
+package mvn
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFormatReports(t *testing.T) {
+	g, err := ResolvePom("shop", "../../testdata/pom/shop", PomParam{Repo: "../../testdata/m2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &Policy{BannedDependencies: []string{"commons-codec"}, MaxDepth: 3}
+	violations, err := p.Check(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(violations) != 1 {
+		t.Fatalf("should have 1 violation, %#v", violations)
+	}
+	param := ReportParam{Poms: g.ModulePoms(), Rules: p.Rules()}
+	service := filepath.FromSlash("../../testdata/pom/shop/shop-service/pom.xml")
+
+	b, err := FormatSARIF(violations, param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%s", b)
+	var sarif sarifLog
+	if err := json.Unmarshal(b, &sarif); err != nil {
+		t.Fatal(err)
+	}
+	if sarif.Version != "2.1.0" || len(sarif.Runs) != 1 || len(sarif.Runs[0].Tool.Driver.Rules) != 2 {
+		t.Fatalf("unexpected sarif, %s", b)
+	}
+	res := sarif.Runs[0].Results
+	if len(res) != 1 || res[0].RuleId != RuleBannedDependencies {
+		t.Fatalf("unexpected results, %s", b)
+	}
+	loc := res[0].Locations[0].PhysicalLocation
+	if loc.ArtifactLocation.Uri != filepath.ToSlash(service) || loc.Region == nil || loc.Region.StartLine != 23 {
+		t.Fatalf("should point to legacy-client in shop-service/pom.xml, %#v", loc)
+	}
+	if fl := res[0].CodeFlows[0].ThreadFlows[0].Locations; len(fl) != 3 || fl[2].Location.Message.Text != "commons-codec:commons-codec:jar:1.11:compile" {
+		t.Fatalf("unexpected code flow, %#v", fl)
+	}
+
+	b, err = FormatJUnit(violations, param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%s", b)
+	var junit junitTestSuites
+	if err := xml.Unmarshal(b, &junit); err != nil {
+		t.Fatal(err)
+	}
+	if junit.Tests != 2 || junit.Failures != 1 || len(junit.Suites) != 2 {
+		t.Fatalf("unexpected junit, %s", b)
+	}
+	if c := junit.Suites[0].Cases[0]; c.Failure == nil || c.File != service || c.Line != 23 {
+		t.Fatalf("unexpected test case, %#v", c)
+	}
+	if c := junit.Suites[1].Cases[0]; c.Failure != nil {
+		t.Fatalf("%v should pass, %#v", junit.Suites[1].Name, c)
+	}
+}
+
+func TestFindDependencyLine(t *testing.T) {
+	pom := `<project>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <artifactId>legacy-client</artifactId>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+  <build>
+    <plugins>
+      <plugin>
+        <dependencies>
+          <dependency><artifactId>legacy-client</artifactId></dependency>
+        </dependencies>
+      </plugin>
+    </plugins>
+  </build>
+  <dependencies>
+    <dependency>
+      <groupId>com.example</groupId>
+      <artifactId>
+        legacy-client
+      </artifactId>
+    </dependency>
+    <dependency>
+      <groupId>org.vendor</groupId>
+      <artifactId>servlet-api</artifactId>
+    </dependency>
+    <dependency>
+      <artifactId>servlet-api</artifactId>
+      <groupId>javax.servlet</groupId>
+    </dependency>
+  </dependencies>
+</project>
+`
+	path := filepath.Join(t.TempDir(), "pom.xml")
+	if err := os.WriteFile(path, []byte(pom), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if ln := findDependencyLine(path, Coordinate{GroupId: "com.example", ArtifactId: "legacy-client"}); ln != 21 {
+		t.Fatalf("should point to the declaration instead of the managed one, %v", ln)
+	}
+	if ln := findDependencyLine(path, Coordinate{GroupId: "com.example", ArtifactId: "legacy-util"}); ln != 0 {
+		t.Fatalf("legacy-util is not declared, %v", ln)
+	}
+	// artifacts sharing the same artifactId are told apart by groupId
+	if ln := findDependencyLine(path, Coordinate{GroupId: "javax.servlet", ArtifactId: "servlet-api"}); ln != 30 {
+		t.Fatalf("should point to javax.servlet:servlet-api, %v", ln)
+	}
+	if ln := findDependencyLine(path, Coordinate{GroupId: "org.vendor", ArtifactId: "servlet-api"}); ln != 27 {
+		t.Fatalf("should point to org.vendor:servlet-api, %v", ln)
+	}
+	if ln := findDependencyLine(path, Coordinate{GroupId: "com.other", ArtifactId: "servlet-api"}); ln != 0 {
+		t.Fatalf("com.other:servlet-api is not declared, %v", ln)
+	}
+}
@@ -0,0 +1,188 @@
+package mvn
+
+import (
+	"fmt"
+)
+
+// Node of resolved dependency tree.
+type resolvedNode struct {
+	coord    Coordinate
+	dep      Dependency // dependency from the parent, omitted nodes are only included in verbose mode
+	children []*resolvedNode
+}
+
+// Dependency waiting to be resolved.
+type pendingDependency struct {
+	parent     *resolvedNode
+	dep        PomDependency
+	exclusions []Exclusion // exclusions declared along the path, including the ones of dep
+	managed    string      // version before dependencyManagement is applied
+}
+
+// Resolve transitive dependencies of pom.xml and its modules using poms in the local repository without running mvn.
+//
+// Maven's dependency mediation is applied, i.e., the nearest version wins (the first declared one wins if they are
+// at the same depth), exclusions are applied, transitive optional dependencies are ignored, and scopes are propagated
+// as described in https://maven.apache.org/guides/introduction/introduction-to-dependency-mechanism.html.
+// dependencyManagement of the project applies to transitive dependencies as well.
+//
+// The graph is built as if it's parsed from the output of mvn dependency:tree using ParseMvnGraph, each module is a
+// separate tree. If the pom of any dependency is not found in the local repository, ErrPomNotFound is returned.
+//
+// With PomParam.Verbose, dependencies omitted for duplicate or conflict are included as omitted edges like
+// mvn dependency:tree -Dverbose, and PomParam.Versions can be used to override versions of artifacts to see how the
+// tree would look like after an upgrade.
+func ResolvePom(title string, path string, p PomParam) (*MvnGraph, error) {
+	return resolvePom(title, path, p, nil)
+}
+
+// Resolve pom, node ids of the previous graph are reused if it's not nil.
+func resolvePom(title string, path string, p PomParam, prev *MvnGraph) (*MvnGraph, error) {
+	l := newPomLoader(p)
+	if _, err := l.loadReactor(path); err != nil {
+		return nil, err
+	}
+	b := newTreeBuilder()
+	if prev != nil {
+		b.reuseIds(prev)
+	}
+	for _, m := range l.modules {
+		e, err := l.load(m)
+		if err != nil {
+			return nil, err
+		}
+		root, err := l.resolve(e)
+		if err != nil {
+			return nil, err
+		}
+		r := b.addRoot(root.coord)
+		addResolved(b, r, root, 1)
+	}
+	return l.build(b, title)
+}
+
+// Add resolved tree to the builder in pre-order, the same order used by mvn dependency:tree.
+func addResolved(b *treeBuilder, p *treeEntry, n *resolvedNode, layer int) {
+	for _, c := range n.children {
+		v := b.add(c.coord, layer)
+		dep := c.dep
+		dep.Scope = c.coord.Scope
+		b.connect(p, v, dep)
+		addResolved(b, v, c, layer+1)
+	}
+}
+
+// Resolve dependency tree of the effective pom, dependencies are visited breadth-first to find the nearest ones.
+func (l *pomLoader) resolve(project *Pom) (*resolvedNode, error) {
+	root := &resolvedNode{coord: project.Coordinate()}
+	managed := map[string]PomDependency{}
+	for _, d := range project.DependencyManagement {
+		managed[d.managementKey()] = d
+	}
+	selected := map[string]string{} // management key -> selected version
+	selected[PomDependency{GroupId: project.GroupId, ArtifactId: project.ArtifactId, Type: project.Packaging}.managementKey()] = project.Version
+
+	queue := make([]pendingDependency, 0, len(project.Dependencies))
+	for _, d := range project.Dependencies {
+		queue = append(queue, pendingDependency{parent: root, dep: d, exclusions: d.Exclusions})
+	}
+	for len(queue) > 0 {
+		pd := queue[0]
+		queue = queue[1:]
+
+		if v, ok := l.pins[pd.dep.GroupId+":"+pd.dep.ArtifactId]; ok {
+			pd.dep.Version = v
+		}
+		k := pd.dep.managementKey()
+		if sv, ok := selected[k]; ok {
+			// nearer or previously declared one wins
+			if l.verbose {
+				c := pd.dep.Coordinate()
+				if v, err := l.resolveVersion(c.GroupId, c.ArtifactId, c.Version); err == nil {
+					c.Version = v
+				}
+				dep := Dependency{Kind: EdgeOmittedDuplicate, VersionManagedFrom: pd.managed}
+				if c.Version != sv {
+					dep.Kind, dep.ConflictWith = EdgeOmittedConflict, sv
+				}
+				pd.parent.children = append(pd.parent.children, &resolvedNode{coord: c, dep: dep})
+			}
+			continue
+		}
+
+		v, err := l.resolveVersion(pd.dep.GroupId, pd.dep.ArtifactId, pd.dep.Version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %v, %w", pd.dep.Coordinate(), err)
+		}
+		pd.dep.Version = v
+		selected[k] = v
+
+		n := &resolvedNode{coord: pd.dep.Coordinate(), dep: Dependency{Kind: EdgeResolved}}
+		if l.verbose {
+			n.dep.VersionManagedFrom = pd.managed
+		}
+		pd.parent.children = append(pd.parent.children, n)
+
+		rp, err := l.find(pd.dep.GroupId, pd.dep.ArtifactId, pd.dep.Version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %v, %w", n.coord, err)
+		}
+		dp, err := l.load(rp)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %v, %w", n.coord, err)
+		}
+		for _, d := range dp.Dependencies {
+			if d.Optional || excluded(pd.exclusions, d.Coordinate()) {
+				continue
+			}
+			if d.Scope == ScopeTest || d.Scope == ScopeProvided || d.Scope == ScopeSystem {
+				continue // not transitive
+			}
+			managedFrom := ""
+			if m, ok := managed[d.managementKey()]; ok {
+				if m.Version != "" && m.Version != d.Version {
+					managedFrom, d.Version = d.Version, m.Version
+				}
+				if m.Scope != "" {
+					d.Scope = m.Scope
+				}
+				d.Exclusions = append(append([]Exclusion{}, d.Exclusions...), m.Exclusions...)
+			}
+			d.Scope = propagateScope(n.coord.Scope, d.Scope)
+			if d.Scope == "" {
+				continue
+			}
+			ex := append(append([]Exclusion{}, pd.exclusions...), d.Exclusions...)
+			queue = append(queue, pendingDependency{parent: n, dep: d, exclusions: ex, managed: managedFrom})
+		}
+	}
+	return root, nil
+}
+
+func excluded(exclusions []Exclusion, c Coordinate) bool {
+	for _, e := range exclusions {
+		if e.Excludes(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// Scope of transitive dependency, empty if the dependency is not included.
+func propagateScope(parent string, scope string) string {
+	if scope == "" {
+		scope = ScopeCompile
+	}
+	if scope != ScopeCompile && scope != ScopeRuntime {
+		return ""
+	}
+	switch parent {
+	case ScopeCompile:
+		return scope
+	case ScopeRuntime:
+		return ScopeRuntime
+	case ScopeProvided, ScopeTest:
+		return parent
+	}
+	return ""
+}
@@ -1,179 +1,556 @@
 package mvn
 
 import (
+	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/curtisnewbie/grapher/graph"
+	"github.com/curtisnewbie/grapher/parser/mvn/version"
 )
 
-func ParseMvnTree(title string, s string) (*graph.DGraph, error) {
-	lines := strings.Split(s, "\n")
-
-	seg := [][]string{}
-	{
-		inSeg := false
-		se := []string{}
-		for _, l := range lines {
-			if !strings.HasPrefix(l, "[INFO]") {
-				continue
-			}
-			if !inSeg && strings.HasPrefix(l, "[INFO] --- dependency:") {
-				inSeg = true
-				continue
-			} else if inSeg && (strings.TrimSpace(l) == "[INFO]" || strings.HasPrefix(l, "[INFO] ----")) {
-				inSeg = false
-				seg = append(seg, se)
-				se = []string{}
-				continue
-			}
-			if inSeg {
-				se = append(se, l)
-			}
+// Graph parsed from output of mvn dependency:tree, the Coordinate of each node is preserved.
+type MvnGraph struct {
+	*graph.DGraph
+	coords      map[int]Coordinate    // node id -> coordinate
+	deps        map[[2]int]Dependency // [from id, to id] -> dependency
+	modules     []int                 // node ids of the root of each tree, i.e., reactor modules
+	nodeModules map[int][]int         // node id -> modules that include the node
+	edgeModules map[[2]int][]int      // [from id, to id] -> modules that include the edge
+	modulePoms  map[string]string     // coordinate key of module -> path of pom.xml, only available if parsed from pom.xml
+	sizes       map[int]int64         // node id -> size of the artifact file, only available after AnnotateSizes
+	verbose     bool                  // whether the graph is resolved in verbose mode
+}
+
+// Find Coordinate of the node.
+func (g *MvnGraph) Coordinate(id int) (Coordinate, bool) {
+	c, ok := g.coords[id]
+	return c, ok
+}
+
+// Find Dependency between the two nodes.
+func (g *MvnGraph) Dependency(fromId int, toId int) (Dependency, bool) {
+	d, ok := g.deps[[2]int{fromId, toId}]
+	return d, ok
+}
+
+// Node ids of reactor modules, i.e., the root of each dependency tree, in the order they are found.
+func (g *MvnGraph) Modules() []int {
+	return append([]int{}, g.modules...)
+}
+
+// Node ids of reactor modules whose dependency tree includes the node.
+func (g *MvnGraph) NodeModules(id int) []int {
+	return append([]int{}, g.nodeModules[id]...)
+}
+
+// Paths of pom.xml of reactor modules, keyed by Coordinate.Key() of the module, only available if the graph is
+// built from pom.xml, e.g., ParsePom and ResolvePom.
+func (g *MvnGraph) ModulePoms() map[string]string {
+	m := make(map[string]string, len(g.modulePoms))
+	for k, v := range g.modulePoms {
+		m[k] = v
+	}
+	return m
+}
+
+// Whether the graph includes dependencies omitted from the resolved tree, i.e., it's resolved by ResolvePom in verbose
+// mode, or it's parsed from the output of mvn dependency:tree -Dverbose. Output of -Dverbose without any omitted
+// dependency is indistinguishable from the one without -Dverbose, it's reported as not verbose.
+//
+// Conflicts are only found in verbose graphs.
+func (g *MvnGraph) Verbose() bool {
+	if g.verbose {
+		return true
+	}
+	for _, d := range g.deps {
+		if d.Omitted() {
+			return true
 		}
 	}
+	return false
+}
 
-	id := 0
-	type Entry struct {
-		Id           int
-		Name         string
-		Layer        int
-		Dependencies []string
+// Whether the node is the root of a reactor module.
+func (g *MvnGraph) IsModule(id int) bool {
+	for _, m := range g.modules {
+		if m == id {
+			return true
+		}
 	}
+	return false
+}
 
-	nodeMap := map[string]*Entry{}
-	newEntry := func(l string, layer int) *Entry {
-		v, ok := nodeMap[l]
-		if !ok {
-			id++
-			v = &Entry{Name: l, Dependencies: []string{}, Layer: layer, Id: id}
-			nodeMap[l] = v
-		} else {
-			v.Layer = layer
+// Build graph of the given reactor module, only the nodes and edges of the module's dependency tree are included.
+//
+// Node ids are preserved.
+func (g *MvnGraph) ModuleSubgraph(moduleId int) (*MvnGraph, error) {
+	if !g.IsModule(moduleId) {
+		return nil, fmt.Errorf("module %v not found", moduleId)
+	}
+	in := func(modules []int) bool {
+		for _, m := range modules {
+			if m == moduleId {
+				return true
+			}
 		}
-		return v
+		return false
 	}
-	addDep := func(p *Entry, l string) {
-		found := false
-		for _, d := range p.Dependencies {
-			if d == l {
-				found = true
+	root, _ := g.Node(moduleId)
+	sub, err := g.filter(root.Label, func(n graph.Node) bool {
+		return in(g.nodeModules[n.Id])
+	}, func(ed graph.DEdge) bool {
+		return in(g.edgeModules[[2]int{ed.FromId, ed.ToId}])
+	})
+	if err != nil {
+		return nil, err
+	}
+	sub.modules = []int{moduleId}
+	for k := range sub.modulePoms {
+		if k != g.coords[moduleId].Key() {
+			delete(sub.modulePoms, k)
+		}
+	}
+	for id := range sub.nodeModules {
+		sub.nodeModules[id] = []int{moduleId}
+	}
+	for k := range sub.edgeModules {
+		sub.edgeModules[k] = []int{moduleId}
+	}
+	return sub, nil
+}
+
+// Build graph with the nodes and edges that match the predicates, node ids are preserved, edges of removed nodes are
+// removed as well.
+func (g *MvnGraph) filter(title string, nf func(n graph.Node) bool, ef func(ed graph.DEdge) bool) (*MvnGraph, error) {
+	sub := &MvnGraph{
+		coords:      map[int]Coordinate{},
+		deps:        map[[2]int]Dependency{},
+		modules:     []int{},
+		nodeModules: map[int][]int{},
+		edgeModules: map[[2]int][]int{},
+		modulePoms:  map[string]string{},
+		sizes:       map[int]int64{},
+		verbose:     g.verbose,
+	}
+	nodes := []graph.Node{}
+	for _, n := range g.Nodes() {
+		if !nf(n) {
+			continue
+		}
+		n.Cluster = ""
+		nodes = append(nodes, n)
+		sub.coords[n.Id] = g.coords[n.Id]
+		sub.nodeModules[n.Id] = g.nodeModules[n.Id]
+		if size, ok := g.sizes[n.Id]; ok {
+			sub.sizes[n.Id] = size
+		}
+		if g.IsModule(n.Id) {
+			sub.modules = append(sub.modules, n.Id)
+			if p, ok := g.modulePoms[g.coords[n.Id].Key()]; ok {
+				sub.modulePoms[g.coords[n.Id].Key()] = p
 			}
 		}
-		if !found {
-			p.Dependencies = append(p.Dependencies, l)
+	}
+	edges := []graph.DEdge{}
+	for _, ed := range g.Edges() {
+		k := [2]int{ed.FromId, ed.ToId}
+		_, from := sub.coords[ed.FromId]
+		_, to := sub.coords[ed.ToId]
+		if !from || !to || !ef(ed) {
+			continue
 		}
+		edges = append(edges, ed)
+		sub.deps[k] = g.deps[k]
+		sub.edgeModules[k] = g.edgeModules[k]
+	}
+
+	d, err := graph.NewDGraph(title, nodes, edges)
+	if err != nil {
+		return nil, err
 	}
+	d.DisplayId = g.DisplayId
+	sub.DGraph = d
+	return sub, nil
+}
 
-	for _, se := range seg {
-		currLayer := 0
-		parents := []*Entry{}
-		for _, l := range se {
-			l = strings.TrimSpace(l)
-			if l == "" {
+// Draw each reactor module as a cluster, nodes that are only included by one module are drawn in the module's
+// cluster, nodes shared by multiple modules are drawn outside of the clusters.
+func (g *MvnGraph) ClusterByModule() {
+	for _, n := range g.Nodes() {
+		cluster := ""
+		if g.IsModule(n.Id) {
+			cluster = g.coords[n.Id].GroupArtifact()
+		} else if m := g.nodeModules[n.Id]; len(m) == 1 {
+			cluster = g.coords[m[0]].GroupArtifact()
+		}
+		g.UpdateNode(n.Id, func(n *graph.Node) { n.Cluster = cluster })
+	}
+}
+
+// Find nodes of the artifact, i.e., nodes with the same groupId and artifactId regardless of the versions.
+func (g *MvnGraph) FindArtifact(groupId string, artifactId string) []graph.Node {
+	return g.FindNode(func(n graph.Node) bool {
+		c, ok := g.coords[n.Id]
+		return ok && c.GroupId == groupId && c.ArtifactId == artifactId
+	})
+}
+
+// Nodes reachable from the node through edges of the module that are accepted by follow, including the node itself.
+func (g *MvnGraph) moduleReachable(module int, from int, follow func(d Dependency) bool) map[int]struct{} {
+	met := map[int]struct{}{from: {}}
+	queue := []int{from}
+	for len(queue) > 0 {
+		pop := queue[0]
+		queue = queue[1:]
+		for _, ed := range g.OutEdges(pop) {
+			k := [2]int{ed.FromId, ed.ToId}
+			if _, ok := met[ed.ToId]; ok || !containsInt(g.edgeModules[k], module) || !follow(g.deps[k]) {
 				continue
 			}
+			met[ed.ToId] = struct{}{}
+			queue = append(queue, ed.ToId)
+		}
+	}
+	return met
+}
 
-			l = l[7:] // "[INFO] "
-			idt := 0
+func containsInt(s []int, v int) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
 
-			for _, r := range l {
-				switch r {
-				case '+', '-', ' ', '|', '\\':
-					idt += 1
-				default:
-					goto PARSE_INDENT_END
-				}
-			}
-		PARSE_INDENT_END:
+// Highest version of the artifact present in the graph, false if the artifact is not found.
+func (g *MvnGraph) HighestVersion(groupId string, artifactId string) (Coordinate, bool) {
+	var highest Coordinate
+	found := false
+	for _, n := range g.FindArtifact(groupId, artifactId) {
+		c := g.coords[n.Id]
+		if !found || c.CompareVersion(highest) > 0 {
+			highest, found = c, true
+		}
+	}
+	return highest, found
+}
 
-			layer := 0
-			if idt > 0 {
-				layer = int(idt / 3)
-			}
-			l = l[idt:]
-
-			{
-				var ok bool
-				l, ok = strings.CutSuffix(l, ":compile")
-				if ok {
-					goto CUT_SUF_END
-				}
-				l, ok = strings.CutSuffix(l, ":test")
-				if ok {
-					goto CUT_SUF_END
-				}
-				l, ok = strings.CutSuffix(l, ":provided")
-				if ok {
-					goto CUT_SUF_END
-				}
-				l, _ = strings.CutSuffix(l, ":runtime")
-			}
+// Find nodes of the artifact with versions within the version range, e.g., [1.0,2.0), a plain version only matches
+// the same version, e.g., 1.0 matches 1.0.0.
+func (g *MvnGraph) FindInRange(groupId string, artifactId string, spec string) ([]graph.Node, error) {
+	r, err := version.ParseRange(spec)
+	if err != nil {
+		return nil, err
+	}
+	nodes := []graph.Node{}
+	for _, n := range g.FindArtifact(groupId, artifactId) {
+		c := g.coords[n.Id]
+		if (r.IsVersion() && c.ParsedVersion().Equal(*r.Recommended)) || (!r.IsVersion() && c.InRange(r)) {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes, nil
+}
+
+// Parse output of mvn dependency:tree.
+func ParseMvnTree(title string, s string) (*graph.DGraph, error) {
+	g, err := ParseMvnGraph(title, s)
+	if err != nil {
+		return nil, err
+	}
+	return g.DGraph, nil
+}
+
+// Parse output of mvn dependency:tree, same as ParseMvnTree but the Coordinate of each node is preserved.
+//
+// Colored output, batch mode, quiet mode, output written by -DoutputFile and CRLF line endings are supported.
+//
+// If the output is malformed, *LineError is returned, if no dependency tree is found, ErrNoDependencyTree is returned.
+func ParseMvnGraph(title string, s string) (*MvnGraph, error) {
+	return parseText(title, strings.NewReader(s), newTextParser())
+}
+
+var (
+	ansiRegex       = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+	treeHeaderRegex = regexp.MustCompile(`^--- (maven-)?dependency(-plugin)?:\S+:tree\b`) // e.g., '--- dependency:3.6.1:tree (default-cli) @ app ---'
+)
 
-		CUT_SUF_END:
-
-			if len(parents) < 1 {
-				v := newEntry(l, layer)
-				parents = append(parents, v)
-				currLayer = layer
-			} else {
-				if layer <= currLayer {
-					parents = parents[:len(parents)-1]
-					for len(parents) > 1 && layer <= parents[len(parents)-1].Layer {
-						parents = parents[:len(parents)-1]
-					}
-
-					// log.Debugf("l: %v", l)
-					p := parents[len(parents)-1]
-					addDep(p, l)
-
-					v := newEntry(l, layer)
-					parents = append(parents, v)
-					currLayer = layer
-				} else if layer > currLayer {
-					p := parents[len(parents)-1]
-					addDep(p, l)
-
-					v := newEntry(l, layer)
-					parents = append(parents, v)
-					currLayer = layer
-
-				} else {
-					p := parents[len(parents)-1]
-					addDep(p, l)
-					newEntry(l, layer)
-				}
+// Parser of text output of mvn dependency:tree, lines are fed one by one.
+//
+// A tree starts with a coordinate without indentation (the root), and ends with an empty line, a separator line
+// (e.g., '[INFO] -----'), a plugin execution marker (e.g., '[INFO] --- dependency:3.6.1:tree') or a new root. Lines
+// logged at levels other than INFO are ignored.
+type textParser struct {
+	b       *treeBuilder
+	parents []*treeEntry // parents[i] is the last entry at layer i of the current tree
+	trees   int
+	header  bool        // whether the header of dependency:tree is found, the next coordinate is the root
+	pending *Coordinate // root without the header, it's only added if the next line is its dependency
+}
+
+func newTextParser() *textParser {
+	return &textParser{b: newTreeBuilder()}
+}
+
+// Strip colors, line endings and log level prefix, return false if the line should be ignored.
+func cleanLine(l string) (string, bool) {
+	l = strings.TrimRight(l, "\r\n")
+	if strings.Contains(l, "\x1b") {
+		l = ansiRegex.ReplaceAllString(l, "")
+	}
+	if strings.HasPrefix(l, "[") {
+		if i := strings.Index(l, "]"); i > -1 {
+			switch l[1:i] {
+			case "INFO":
+				l = strings.TrimPrefix(l[i+1:], " ")
+			case "WARNING", "WARN", "ERROR", "DEBUG":
+				return "", false
 			}
 		}
 	}
+	return strings.TrimRight(l, " \t"), true
+}
 
-	// for k, v := range nodeMap {
-	// 	fmt.Printf("[debug] k: %v, v: %#v\n", k, *v)
-	// }
+func (p *textParser) feed(ln int, raw string) error {
+	l, ok := cleanLine(raw)
+	if !ok {
+		return nil
+	}
 
-	nodes := make([]graph.Node, 0, len(nodeMap))
-	edges := make([]graph.DEdge, 0)
-	for k := range nodeMap {
-		n := nodeMap[k]
-		tkn := strings.Split(n.Name, ":")
-		label := tkn[0]
-		if len(tkn) > 1 {
-			label += "\n" + tkn[1]
+	// end of tree, e.g., '[INFO]', '[INFO] -----', '[INFO] --- dependency:3.6.1:tree', '[INFO] BUILD SUCCESS'
+	if strings.TrimSpace(l) == "" || strings.HasPrefix(l, "---") || strings.HasPrefix(l, "BUILD ") {
+		p.parents = nil
+		p.pending = nil
+		if strings.TrimSpace(l) != "" {
+			p.header = treeHeaderRegex.MatchString(l)
+		}
+		return nil
+	}
+
+	idt := 0
+	for _, r := range l {
+		switch r {
+		case '+', '-', ' ', '|', '\\':
+			idt += 1
+		default:
+			goto PARSE_INDENT_END
+		}
+	}
+PARSE_INDENT_END:
+
+	// root without the header is confirmed by its first dependency, e.g., output of mvn dependency:tree -q
+	if p.pending != nil {
+		if idt == 3 && (strings.HasPrefix(l, "+- ") || strings.HasPrefix(l, "\\- ")) {
+			p.addRoot(*p.pending)
 		}
-		if len(tkn) > 2 {
-			label += "\n" + strings.Join(tkn[2:], ":")
+		p.pending = nil
+	}
+
+	// unindented line ends the current tree, e.g., tree of another module written to the same file
+	if idt == 0 {
+		p.parents = nil
+	}
+
+	// outside of a tree, lines that are not root coordinates are ignored, e.g., 'Building todo-app 2.9', and
+	// coordinates that are neither after the header nor followed by dependencies are ignored as well, e.g., logs of
+	// other plugins
+	if len(p.parents) < 1 {
+		if idt > 0 {
+			return nil
+		}
+		c, err := ParseCoordinate(l)
+		if err != nil {
+			return nil
 		}
+		if p.header {
+			p.header = false
+			p.addRoot(c)
+		} else {
+			p.pending = &c
+		}
+		return nil
+	}
+
+	if idt%3 != 0 {
+		return &LineError{Line: ln, Text: raw, Err: ErrInvalidIndent}
+	}
+	layer := idt / 3
+	if layer > len(p.parents) {
+		return &LineError{Line: ln, Text: raw, Err: ErrInvalidIndent}
+	}
+
+	c, dep, err := parseTreeEntry(l[idt:])
+	if err != nil {
+		return &LineError{Line: ln, Text: raw, Err: fmt.Errorf("%w, %v", ErrInvalidEntry, err)}
+	}
+
+	parent := p.parents[layer-1]
+	v := p.b.add(c, layer)
+	p.b.connect(parent, v, dep)
+	p.parents = append(p.parents[:layer], v)
+	return nil
+}
+
+func (p *textParser) addRoot(c Coordinate) {
+	p.parents = []*treeEntry{p.b.addRoot(c)}
+	p.trees++
+}
+
+func (p *textParser) finish(title string) (*MvnGraph, error) {
+	if p.trees < 1 {
+		return nil, ErrNoDependencyTree
+	}
+	return p.b.build(title)
+}
+
+type treeEntry struct {
+	id        int
+	coord     Coordinate
+	layer     int
+	deps      []*treeEntry
+	relations map[int]Dependency // id of dependency -> dependency
+}
+
+// Builder of MvnGraph shared by parsers of different formats, nodes are keyed by Coordinate.Key().
+type treeBuilder struct {
+	entries  []*treeEntry // in the order they are found, for deterministic output
+	entryMap map[string]*treeEntry
+
+	roots       []*treeEntry // root of each tree, i.e., reactor modules
+	module      *treeEntry   // root of current tree
+	nodeModules map[int][]int
+	edgeModules map[[2]int][]int
+
+	ids    map[string]int // ids reserved for coordinates, see reuseIds
+	lastId int
+}
+
+func newTreeBuilder() *treeBuilder {
+	return &treeBuilder{
+		entries:     []*treeEntry{},
+		entryMap:    map[string]*treeEntry{},
+		roots:       []*treeEntry{},
+		nodeModules: map[int][]int{},
+		edgeModules: map[[2]int][]int{},
+	}
+}
+
+// Add root of a new tree, nodes and edges added afterwards belong to the tree.
+func (b *treeBuilder) addRoot(c Coordinate) *treeEntry {
+	v := b.add(c, 0)
+	found := false
+	for _, r := range b.roots {
+		if r == v {
+			found = true
+			break
+		}
+	}
+	if !found {
+		b.roots = append(b.roots, v)
+	}
+	b.module = v
+	b.nodeModules[v.id] = appendModule(b.nodeModules[v.id], v.id)
+	return v
+}
+
+func appendModule(modules []int, m int) []int {
+	for _, v := range modules {
+		if v == m {
+			return modules
+		}
+	}
+	return append(modules, m)
+}
+
+// Add node of the coordinate, if the node already exists, the existing one is returned with the layer updated.
+func (b *treeBuilder) add(c Coordinate, layer int) *treeEntry {
+	k := c.Key()
+	v, ok := b.entryMap[k]
+	if !ok {
+		id, reserved := b.ids[k]
+		if !reserved {
+			b.lastId++
+			id = b.lastId
+		}
+		v = &treeEntry{id: id, coord: c, layer: layer, deps: []*treeEntry{}, relations: map[int]Dependency{}}
+		b.entryMap[k] = v
+		b.entries = append(b.entries, v)
+	} else {
+		v.layer = layer
+	}
+	return v
+}
+
+// Reuse node ids of the graph, nodes with the same Coordinate.Key() get the same ids, and new nodes get ids that are
+// not used by the graph. Must be called before any node is added.
+func (b *treeBuilder) reuseIds(g *MvnGraph) {
+	b.ids = map[string]int{}
+	for id, c := range g.coords {
+		b.ids[c.Key()] = id
+		if id > b.lastId {
+			b.lastId = id
+		}
+	}
+}
+
+// Connect parent to the dependency, resolved dependency takes precedence over the omitted one.
+func (b *treeBuilder) connect(p *treeEntry, d *treeEntry, dep Dependency) {
+	prev, found := p.relations[d.id]
+	if !found {
+		p.deps = append(p.deps, d)
+	}
+	if !found || (prev.Omitted() && !dep.Omitted()) {
+		p.relations[d.id] = dep
+	}
+	if b.module != nil {
+		k := [2]int{p.id, d.id}
+		b.edgeModules[k] = appendModule(b.edgeModules[k], b.module.id)
+		b.nodeModules[d.id] = appendModule(b.nodeModules[d.id], b.module.id)
+	}
+}
+
+func (b *treeBuilder) build(title string) (*MvnGraph, error) {
+	nodes := make([]graph.Node, 0, len(b.entries))
+	edges := make([]graph.DEdge, 0)
+	coords := make(map[int]Coordinate, len(b.entries))
+	deps := map[[2]int]Dependency{}
+	roots := map[int]struct{}{}
+	modules := make([]int, 0, len(b.roots))
+	for _, r := range b.roots {
+		roots[r.id] = struct{}{}
+		modules = append(modules, r.id)
+	}
+	for _, n := range b.entries {
 		nodes = append(nodes, graph.Node{
-			Id:    n.Id,
-			Label: label,
+			Id:    n.id,
+			Label: n.coord.label(),
+			Attrs: n.coord.Attrs(),
 		})
-		for _, dl := range n.Dependencies {
-			d := nodeMap[dl]
-			edges = append(edges, graph.DEdge{FromId: n.Id, ToId: d.Id})
+		coords[n.id] = n.coord
+		for _, d := range n.deps {
+			dep := n.relations[d.id]
+			dep.FromId = n.id
+			dep.ToId = d.id
+			if _, ok := roots[d.id]; ok && len(roots) > 1 {
+				dep.InterModule = true
+			}
+			deps[[2]int{n.id, d.id}] = dep
+			edges = append(edges, dep.edge())
 		}
 	}
 	d, err := graph.NewDGraph(title, nodes, edges)
 	if err != nil {
 		return nil, err
 	}
-	return d, nil
+	return &MvnGraph{
+		DGraph:      d,
+		coords:      coords,
+		deps:        deps,
+		modules:     modules,
+		nodeModules: b.nodeModules,
+		edgeModules: b.edgeModules,
+		modulePoms:  map[string]string{},
+	}, nil
 }
@@ -1,7 +1,9 @@
 package mvn
 
 import (
+	"errors"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/curtisnewbie/grapher/graph"
@@ -26,3 +28,329 @@ func TestParseMvnTree(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestParseMvnTreeAttrs(t *testing.T) {
+	ctn, err := os.ReadFile("../../testdata/todoapp_mvn.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := ParseMvnTree("dependency tree", string(ctn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := graph.ParseFilter("group:com.fasterxml* and not scope:test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := g.FindNode(f.Predicate(g))
+	if len(found) < 1 {
+		t.Fatal("should find com.fasterxml nodes")
+	}
+	for _, n := range found {
+		if n.Attrs[AttrVersion] == "" || n.Attrs[AttrArtifact] == "" {
+			t.Fatalf("missing attributes, %#v", n)
+		}
+	}
+}
+
+func TestParseMvnTreeDeterministic(t *testing.T) {
+	ctn, err := os.ReadFile("../../testdata/todoapp_mvn.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var first string
+	for i := 0; i < 10; i++ {
+		g, err := ParseMvnTree("dependency tree", string(ctn))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := g.SDraw()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			first = s
+		} else if s != first {
+			t.Fatalf("output is not deterministic, expected:\n%v\nactual:\n%v", first, s)
+		}
+	}
+}
+
+func TestParseMvnGraphCoordinate(t *testing.T) {
+	ctn, err := os.ReadFile("../../testdata/todoapp_mvn.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := ParseMvnGraph("dependency tree", string(ctn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := g.FindArtifact("junit", "junit")
+	if len(found) != 1 {
+		t.Fatalf("should find junit, %v", found)
+	}
+	c, ok := g.Coordinate(found[0].Id)
+	if !ok {
+		t.Fatal("should find coordinate of junit")
+	}
+	if c.Version != "3.8.1" || c.Scope != ScopeTest || c.Packaging != "jar" {
+		t.Fatalf("unexpected coordinate, %#v", c)
+	}
+}
+
+func TestParseMvnTreeVerbose(t *testing.T) {
+	ctn, err := os.ReadFile("../../testdata/verbose_mvn.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := ParseMvnGraph("dependency tree", string(ctn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := g.SDraw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("s: %v", s)
+
+	find := func(groupId, artifactId, version string) int {
+		for _, n := range g.FindArtifact(groupId, artifactId) {
+			if c, _ := g.Coordinate(n.Id); c.Version == version {
+				return n.Id
+			}
+		}
+		t.Fatalf("%v:%v:%v not found", groupId, artifactId, version)
+		return 0
+	}
+	root := find("com.curtisnewbie", "order-app", "1.0.0")
+	aws := find("com.amazonaws", "aws-java-sdk-core", "1.11.900")
+	mysql := find("mysql", "mysql-connector-java", "8.0.22")
+
+	cases := []struct {
+		from, to int
+		want     Dependency
+	}{
+		{root, find("org.springframework", "spring-core", "5.2.9.RELEASE"), Dependency{Kind: EdgeResolved, Scope: ScopeCompile, VersionManagedFrom: "5.2.8.RELEASE"}},
+		{aws, find("commons-logging", "commons-logging", "1.2"), Dependency{Kind: EdgeOmittedDuplicate, Scope: ScopeCompile, VersionManagedFrom: "1.1.3"}},
+		{aws, find("com.fasterxml.jackson.core", "jackson-databind", "2.11.2"), Dependency{Kind: EdgeOmittedConflict, Scope: ScopeCompile, ConflictWith: "2.12.0"}},
+		{mysql, find("com.google.protobuf", "protobuf-java", "3.11.4"), Dependency{Kind: EdgeOmittedDuplicate, Scope: ScopeCompile, ScopeUpdatedFrom: "runtime"}},
+		{root, find("com.google.protobuf", "protobuf-java", "3.11.4"), Dependency{Kind: EdgeResolved, Scope: ScopeCompile, ScopeManagedFrom: "runtime"}},
+	}
+	for _, c := range cases {
+		d, ok := g.Dependency(c.from, c.to)
+		if !ok {
+			t.Fatalf("%v -> %v not found", c.from, c.to)
+		}
+		c.want.FromId, c.want.ToId = c.from, c.to
+		if d != c.want {
+			t.Fatalf("expected %#v, actual %#v", c.want, d)
+		}
+	}
+
+	if c, _ := g.Coordinate(mysql); !c.Optional {
+		t.Fatalf("mysql should be optional, %#v", c)
+	}
+	if n := len(g.FindNode(func(n graph.Node) bool { return n.Attrs[AttrArtifact] == "" })); n > 0 {
+		t.Fatalf("found %v junk nodes", n)
+	}
+	if g.NodeCount() != 17 {
+		t.Fatalf("should have 17 nodes, %v", g.NodeCount())
+	}
+}
+
+func TestParseMvnTreeVariants(t *testing.T) {
+	ctn, err := os.ReadFile("../../testdata/todoapp_mvn.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := ParseMvnGraph("dependency tree", string(ctn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := g.SDraw()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(string(ctn), "\n")
+	colored := []string{}
+	quiet := []string{}
+	for _, l := range lines {
+		if v, ok := strings.CutPrefix(l, "[INFO] "); ok {
+			if strings.Contains(v, ":jar:") {
+				quiet = append(quiet, v)
+				v = "\x1b[1m" + v + "\x1b[m"
+			}
+			l = "[\x1b[1;34mINFO\x1b[m] " + v
+		}
+		colored = append(colored, l)
+	}
+
+	for name, s := range map[string]string{
+		"crlf":    strings.ReplaceAll(string(ctn), "\n", "\r\n"),
+		"colored": strings.Join(colored, "\n"),
+		"quiet":   strings.Join(quiet, "\n"),
+	} {
+		g, err := ParseMvnGraph("dependency tree", s)
+		if err != nil {
+			t.Fatalf("failed to parse %v output, %v", name, err)
+		}
+		if s, _ := g.SDraw(); s != want {
+			t.Fatalf("%v output is parsed differently, expected:\n%v\nactual:\n%v", name, want, s)
+		}
+	}
+
+	if _, err := ParseMvnGraph("dependency tree", "[INFO] BUILD FAILURE\n"); !errors.Is(err, ErrNoDependencyTree) {
+		t.Fatalf("should return ErrNoDependencyTree, %v", err)
+	}
+
+	malformed := strings.Replace(string(ctn), "+- org.xerial:sqlite-jdbc:jar:3.34.0:compile", "+- org.xerial:sqlite-jdbc:jar:3.34.0:compile oops", 1)
+	_, err = ParseMvnGraph("dependency tree", malformed)
+	var le *LineError
+	if !errors.As(err, &le) || le.Line != 23 || !errors.Is(err, ErrInvalidEntry) {
+		t.Fatalf("should return LineError of line 23, %v", err)
+	}
+	t.Log(err)
+
+	malformed = strings.Replace(string(ctn), "|  \\- com.fasterxml", "|     \\- com.fasterxml", 1)
+	if _, err = ParseMvnGraph("dependency tree", malformed); !errors.As(err, &le) || le.Line != 22 || !errors.Is(err, ErrInvalidIndent) {
+		t.Fatalf("should return LineError of line 22, %v", err)
+	}
+}
+
+func FuzzParseMvnGraph(f *testing.F) {
+	for _, file := range []string{"../../testdata/todoapp_mvn.out", "../../testdata/verbose_mvn.out"} {
+		ctn, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(string(ctn))
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		g, err := ParseMvnGraph("dependency tree", s)
+		if err != nil {
+			var le *LineError
+			if !errors.As(err, &le) && !errors.Is(err, ErrNoDependencyTree) {
+				t.Fatalf("unexpected error type, %v", err)
+			}
+			return
+		}
+		if g.NodeCount() < 1 {
+			t.Fatal("graph should not be empty")
+		}
+	})
+}
+
+func TestParseMvnTreeReactor(t *testing.T) {
+	ctn, err := os.ReadFile("../../testdata/reactor_mvn.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := ParseMvnGraph("dependency tree", string(ctn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	modules := g.Modules()
+	if len(modules) != 3 {
+		t.Fatalf("should have 3 modules, %v", modules)
+	}
+	module := func(a string) int {
+		n := g.FindArtifact("com.example.shop", a)
+		if len(n) != 1 || !g.IsModule(n[0].Id) {
+			t.Fatalf("module %v not found, %v", a, n)
+		}
+		return n[0].Id
+	}
+	common, service := module("shop-common"), module("shop-service")
+
+	if d, ok := g.Dependency(service, common); !ok || !d.InterModule {
+		t.Fatalf("dependency on shop-common should be inter-module, %#v", d)
+	}
+	for _, ed := range g.Edges() {
+		if ed.Attrs[AttrEdgeInterModule] == "true" && (ed.FromId != service || ed.ToId != common) {
+			t.Fatalf("unexpected inter-module edge, %#v", ed)
+		}
+	}
+
+	jackson := g.FindArtifact("com.fasterxml.jackson.core", "jackson-core")[0].Id
+	if m := g.NodeModules(jackson); len(m) != 1 || m[0] != service {
+		t.Fatalf("jackson-core should only be included by shop-service, %v", m)
+	}
+	guava := g.FindArtifact("com.google.guava", "guava")[0].Id
+	if m := g.NodeModules(guava); len(m) != 2 {
+		t.Fatalf("guava should be included by both shop-common and shop-service, %v", m)
+	}
+
+	sub, err := g.ModuleSubgraph(common)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.NodeCount() != 6 || sub.EdgeCount() != 5 {
+		t.Fatalf("shop-common should have 6 nodes and 5 edges, %v, %v", sub.NodeCount(), sub.EdgeCount())
+	}
+	if _, ok := sub.Node(guava); !ok {
+		t.Fatal("node ids should be preserved")
+	}
+	if _, err := g.ModuleSubgraph(guava); err == nil {
+		t.Fatal("guava is not a module")
+	}
+
+	g.ClusterByModule()
+	n, _ := g.Node(jackson)
+	if n.Cluster != "com.example.shop:shop-service" {
+		t.Fatalf("jackson-core should be in cluster of shop-service, %v", n.Cluster)
+	}
+	if n, _ := g.Node(guava); n.Cluster != "" {
+		t.Fatalf("guava should not be in any cluster, %v", n.Cluster)
+	}
+	s, err := g.SDraw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(s, "subgraph \"cluster_") {
+		t.Fatalf("clusters not drawn:\n%v", s)
+	}
+}
+
+func TestParseMvnTreeStrayCoordinates(t *testing.T) {
+	ctn, err := os.ReadFile("../../testdata/verbose_mvn.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ParseMvnGraph("dependency tree", string(ctn))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// coordinates logged by other plugins are not roots
+	stray := strings.Replace(string(ctn), "[INFO] --- dependency:3.6.1:tree", `[INFO] --- maven-shade-plugin:3.5.0:shade (default) @ order-app ---
+[INFO] com.google.guava:guava:jar:32.1.2-jre
+[INFO] Including org.slf4j:slf4j-api:jar:1.7.36 in the shaded jar.
+[INFO] --- dependency:3.6.1:tree`, 1)
+	stray = strings.Replace(stray, "[INFO] BUILD SUCCESS", "[INFO] org.example:installed:jar:1.0\n[INFO] BUILD SUCCESS", 1)
+	g, err := ParseMvnGraph("dependency tree", stray)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Modules()) != 1 || g.NodeCount() != want.NodeCount() {
+		t.Fatalf("stray coordinates should be ignored, modules: %v, nodes: %v", g.Modules(), g.NodeCount())
+	}
+	if n := g.FindArtifact("org.example", "installed"); len(n) != 0 {
+		t.Fatalf("stray coordinate should not be a module, %v", n)
+	}
+
+	// roots without the header are recognized by their dependencies, a root without any dependency is ignored
+	g, err = ParseMvnGraph("dependency tree", `com.example:a:jar:1.0
+com.example:b:jar:1.0
+\- org.slf4j:slf4j-api:jar:1.7.36:compile
+com.example:c:jar:1.0
++- org.slf4j:slf4j-api:jar:1.7.36:compile
+\- com.google.guava:guava:jar:32.1.2-jre:compile
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Modules()) != 2 || len(g.FindArtifact("com.example", "a")) != 0 {
+		t.Fatalf("only b and c should be modules, %v", g.Modules())
+	}
+}
@@ -0,0 +1,326 @@
+package mvn
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/curtisnewbie/grapher/graph"
+)
+
+const (
+	addedNodeFillColor   = "#d3f9d8"
+	changedNodeFillColor = "#d0ebff"
+)
+
+// Upgrade of an artifact to another version.
+type Upgrade struct {
+	GroupId    string `json:"groupId"`
+	ArtifactId string `json:"artifactId"`
+	Version    string `json:"version"`
+}
+
+// Parse upgrade in the format of groupId:artifactId:version.
+func ParseUpgrade(s string) (Upgrade, error) {
+	tkn := strings.Split(strings.TrimSpace(s), ":")
+	if len(tkn) != 3 || tkn[0] == "" || tkn[1] == "" || tkn[2] == "" {
+		return Upgrade{}, fmt.Errorf("invalid upgrade '%v', should be groupId:artifactId:version", s)
+	}
+	return Upgrade{GroupId: tkn[0], ArtifactId: tkn[1], Version: tkn[2]}, nil
+}
+
+// groupId:artifactId:version of the upgrade.
+func (u Upgrade) String() string {
+	return u.GroupId + ":" + u.ArtifactId + ":" + u.Version
+}
+
+// Change of an artifact on the classpath of modules.
+type ArtifactChange struct {
+	GroupId    string   `json:"groupId"`
+	ArtifactId string   `json:"artifactId"`
+	Packaging  string   `json:"packaging,omitempty"`
+	Classifier string   `json:"classifier,omitempty"`
+	Before     string   `json:"before,omitempty"` // version before the change, empty if the artifact is added
+	After      string   `json:"after,omitempty"`  // version after the change, empty if the artifact is removed
+	Modules    []string `json:"modules"`          // modules whose classpath is changed
+	NodeIds    []int    `json:"-"`                // nodes after the change, or nodes before the change if the artifact is removed
+}
+
+// Artifact of the change, i.e., groupId:artifactId[:packaging][:classifier].
+func (c ArtifactChange) Artifact() string {
+	return Coordinate{GroupId: c.GroupId, ArtifactId: c.ArtifactId, Packaging: c.Packaging, Classifier: c.Classifier}.artifactKey()
+}
+
+// Difference between the classpaths of modules in two graphs.
+type GraphDiff struct {
+	Added             []ArtifactChange `json:"added"`
+	Removed           []ArtifactChange `json:"removed"`
+	Changed           []ArtifactChange `json:"changed"`
+	NewConflicts      []Conflict       `json:"newConflicts"`      // conflicts that are not found before, or found with different versions
+	ResolvedConflicts []Conflict       `json:"resolvedConflicts"` // conflicts that are no longer found
+}
+
+// Whether the graphs are the same.
+func (d *GraphDiff) Empty() bool {
+	return len(d.Added)+len(d.Removed)+len(d.Changed)+len(d.NewConflicts)+len(d.ResolvedConflicts) < 1
+}
+
+// Impact of an upgrade.
+type UpgradeImpact struct {
+	Upgrade Upgrade `json:"upgrade"`
+	GraphDiff
+	Before *MvnGraph `json:"-"`
+	After  *MvnGraph `json:"-"` // nodes have the same ids as the ones in Before if they have the same coordinates
+}
+
+// Simulate upgrade of an artifact without editing poms or running mvn.
+//
+// The pom is resolved twice using ResolvePom in verbose mode, before and after the upgrade. The upgraded version
+// overrides the artifact wherever it's referenced, i.e., as a dependency, a parent or an imported BOM, so that the
+// blast radius of bumping e.g. spring-boot-dependencies can be seen. Node ids of the graph after the upgrade are the
+// same as the graph before the upgrade for the same Coordinate.Key().
+func SimulateUpgrade(title string, path string, u Upgrade, p PomParam) (*UpgradeImpact, error) {
+	p.Verbose = true
+	before, err := resolvePom(title, path, p, nil)
+	if err != nil {
+		return nil, err
+	}
+	return SimulateUpgradeFrom(before, title, path, u, p)
+}
+
+// Simulate upgrade of an artifact against an existing graph, e.g., the one parsed from the output of
+// mvn dependency:tree -Dverbose by ParseMvnGraph, the pom is only resolved once after the upgrade using ResolvePom in
+// verbose mode. Node ids of the graph after the upgrade are the same as the existing graph for the same
+// Coordinate.Key().
+//
+// The existing graph should include all the modules of the pom, and it should be verbose so that the conflicts can be
+// compared, see DiffGraphs.
+func SimulateUpgradeFrom(before *MvnGraph, title string, path string, u Upgrade, p PomParam) (*UpgradeImpact, error) {
+	p.Verbose = true
+	versions := map[string]string{}
+	for k, v := range p.Versions {
+		versions[k] = v
+	}
+	versions[u.GroupId+":"+u.ArtifactId] = u.Version
+	p.Versions = versions
+	after, err := resolvePom(fmt.Sprintf("%v (%v)", title, u), path, p, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upgrade %v, %w", u, err)
+	}
+	return &UpgradeImpact{Upgrade: u, GraphDiff: *DiffGraphs(before, after), Before: before, After: after}, nil
+}
+
+// Diff classpaths of the modules in two graphs, artifacts are identified by groupId, artifactId, packaging and
+// classifier, and modules are identified by Coordinate.Key().
+//
+// The graphs should be built in the same way, e.g., both parsed from the output of mvn dependency:tree -Dverbose, so
+// that the conflicts can be compared.
+func DiffGraphs(before *MvnGraph, after *MvnGraph) *GraphDiff {
+	d := &GraphDiff{
+		Added:             []ArtifactChange{},
+		Removed:           []ArtifactChange{},
+		Changed:           []ArtifactChange{},
+		NewConflicts:      []Conflict{},
+		ResolvedConflicts: []Conflict{},
+	}
+	changes := map[string]*ArtifactChange{}
+	order := []string{}
+	record := func(k string, b *Coordinate, a *Coordinate, module string, id int) {
+		ch, ok := changes[k]
+		if !ok {
+			c := a
+			if c == nil {
+				c = b
+			}
+			ch = &ArtifactChange{GroupId: c.GroupId, ArtifactId: c.ArtifactId, Packaging: c.Packaging, Classifier: c.Classifier}
+			if b != nil {
+				ch.Before = b.Version
+			}
+			if a != nil {
+				ch.After = a.Version
+			}
+			changes[k] = ch
+			order = append(order, k)
+		}
+		ch.Modules = appendString(ch.Modules, module)
+		for _, v := range ch.NodeIds {
+			if v == id {
+				return
+			}
+		}
+		ch.NodeIds = append(ch.NodeIds, id)
+	}
+
+	bcp, acp := before.classpaths(), after.classpaths()
+	modules := []string{}
+	for _, m := range after.modules {
+		modules = appendString(modules, after.coords[m].Key())
+	}
+	for _, m := range before.modules {
+		modules = appendString(modules, before.coords[m].Key())
+	}
+	for _, m := range modules {
+		bm, am := bcp[m], acp[m]
+		for _, id := range am.ids {
+			ac := after.coords[id]
+			ak := ac.artifactKey()
+			bid, ok := bm.artifacts[ak]
+			if !ok {
+				record("+"+ak+":"+ac.Version, nil, &ac, m, id)
+				continue
+			}
+			if bc := before.coords[bid]; bc.Version != ac.Version {
+				record("~"+ak+":"+bc.Version+":"+ac.Version, &bc, &ac, m, id)
+			}
+		}
+		for _, id := range bm.ids {
+			bc := before.coords[id]
+			if _, ok := am.artifacts[bc.artifactKey()]; !ok {
+				record("-"+bc.artifactKey()+":"+bc.Version, &bc, nil, m, id)
+			}
+		}
+	}
+	for _, k := range order {
+		ch := changes[k]
+		switch {
+		case ch.Before == "":
+			d.Added = append(d.Added, *ch)
+		case ch.After == "":
+			d.Removed = append(d.Removed, *ch)
+		default:
+			d.Changed = append(d.Changed, *ch)
+		}
+	}
+
+	bcf := map[string]string{}
+	for _, c := range before.Conflicts() {
+		bcf[c.GroupId+":"+c.ArtifactId] = conflictVersions(c)
+	}
+	acf := map[string]struct{}{}
+	for _, c := range after.Conflicts() {
+		ga := c.GroupId + ":" + c.ArtifactId
+		acf[ga] = struct{}{}
+		if v, ok := bcf[ga]; !ok || v != conflictVersions(c) {
+			d.NewConflicts = append(d.NewConflicts, c)
+		}
+	}
+	for _, c := range before.Conflicts() {
+		if _, ok := acf[c.GroupId+":"+c.ArtifactId]; !ok {
+			d.ResolvedConflicts = append(d.ResolvedConflicts, c)
+		}
+	}
+	return d
+}
+
+// Classpath of a module.
+type classpath struct {
+	ids       []int          // nodes on the classpath, in the order of nodes in the graph
+	artifacts map[string]int // artifact key -> node id
+}
+
+// Classpath of each module keyed by Coordinate.Key() of the module, i.e., nodes reached from the module through
+// resolved edges of the module, the module itself is excluded.
+func (g *MvnGraph) classpaths() map[string]classpath {
+	cps := map[string]classpath{}
+	for _, m := range g.modules {
+		met := g.moduleReachable(m, m, func(d Dependency) bool { return !d.Omitted() })
+		cp := classpath{ids: []int{}, artifacts: map[string]int{}}
+		for _, n := range g.Nodes() {
+			if _, ok := met[n.Id]; ok && n.Id != m {
+				cp.ids = append(cp.ids, n.Id)
+				cp.artifacts[g.coords[n.Id].artifactKey()] = n.Id
+			}
+		}
+		cps[g.coords[m].Key()] = cp
+	}
+	return cps
+}
+
+func conflictVersions(c Conflict) string {
+	versions := make([]string, 0, len(c.Versions))
+	for _, v := range c.Versions {
+		versions = append(versions, v.Version)
+	}
+	sort.Strings(versions)
+	return strings.Join(versions, ",")
+}
+
+func appendString(s []string, v string) []string {
+	for _, e := range s {
+		if e == v {
+			return s
+		}
+	}
+	return append(s, v)
+}
+
+// Highlight nodes that are added or changed and the paths leading to them, the nodes must be in the graph after the
+// change, e.g., UpgradeImpact.After.
+func (g *MvnGraph) HighlightChanges(d *GraphDiff) {
+	fills := map[int]string{}
+	tooltips := map[int]string{}
+	for _, c := range d.Added {
+		for _, id := range c.NodeIds {
+			fills[id] = addedNodeFillColor
+			tooltips[id] = "added"
+		}
+	}
+	for _, c := range d.Changed {
+		for _, id := range c.NodeIds {
+			fills[id] = changedNodeFillColor
+			tooltips[id] = fmt.Sprintf("changed from %v", c.Before)
+		}
+	}
+	g.Highlight(func(n graph.Node) bool {
+		_, ok := fills[n.Id]
+		return ok
+	})
+	for id, fill := range fills {
+		tooltip := tooltips[id]
+		g.UpdateNode(id, func(n *graph.Node) {
+			n.FillColor = fill
+			n.Tooltip = tooltip
+		})
+	}
+}
+
+// Format upgrade impact as human-readable text.
+func FormatUpgradeImpact(u *UpgradeImpact) string {
+	b := strings.Builder{}
+	b.WriteString(fmt.Sprintf("Upgrade %v:%v to %v\n", u.Upgrade.GroupId, u.Upgrade.ArtifactId, u.Upgrade.Version))
+	if u.Empty() {
+		b.WriteString("\nNo change\n")
+		return b.String()
+	}
+	section := func(title string, changes []ArtifactChange, format func(c ArtifactChange) string) {
+		if len(changes) < 1 {
+			return
+		}
+		b.WriteString("\n" + title + ":\n")
+		for _, c := range changes {
+			b.WriteString(fmt.Sprintf("  %v, modules: %v\n", format(c), strings.Join(c.Modules, ", ")))
+		}
+	}
+	section("Changed", u.Changed, func(c ArtifactChange) string {
+		return fmt.Sprintf("%v %v -> %v", c.Artifact(), c.Before, c.After)
+	})
+	section("Added", u.Added, func(c ArtifactChange) string { return c.Artifact() + ":" + c.After })
+	section("Removed", u.Removed, func(c ArtifactChange) string { return c.Artifact() + ":" + c.Before })
+	if len(u.NewConflicts) > 0 {
+		b.WriteString("\nNew conflicts:\n")
+		b.WriteString(FormatConflicts(u.NewConflicts))
+	}
+	if len(u.ResolvedConflicts) > 0 {
+		b.WriteString("\nResolved conflicts:\n")
+		for _, c := range u.ResolvedConflicts {
+			b.WriteString(fmt.Sprintf("  %v:%v, versions: %v\n", c.GroupId, c.ArtifactId, strings.ReplaceAll(conflictVersions(c), ",", ", ")))
+		}
+	}
+	return b.String()
+}
+
+// Format upgrade impact as JSON.
+func FormatUpgradeImpactJSON(u *UpgradeImpact) ([]byte, error) {
+	return json.MarshalIndent(u, "", "  ")
+}
@@ -0,0 +1,152 @@
+package mvn
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSimulateUpgrade(t *testing.T) {
+	u, err := ParseUpgrade("com.example:legacy-client:2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	impact, err := SimulateUpgrade("shop", "../../testdata/pom/shop", u, PomParam{Repo: "../../testdata/m2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("\n%v", FormatUpgradeImpact(impact))
+
+	service := "com.example.shop:shop-service:jar:1.0.0"
+	changed := map[string]string{}
+	for _, c := range impact.Changed {
+		changed[c.Artifact()] = c.Before + " -> " + c.After
+		if len(c.Modules) != 1 || c.Modules[0] != service {
+			t.Fatalf("only shop-service should be changed, %v", c.Modules)
+		}
+	}
+	want := map[string]string{
+		"com.example:legacy-client:jar":   "1.0 -> 2.0",
+		"commons-codec:commons-codec:jar": "1.11 -> 1.15",
+	}
+	if len(changed) != len(want) {
+		t.Fatalf("unexpected changes, %v", changed)
+	}
+	for k, v := range want {
+		if changed[k] != v {
+			t.Fatalf("%v should be changed %v, %v", k, v, changed[k])
+		}
+	}
+	if len(impact.Added) != 1 || impact.Added[0].Artifact() != "com.example:legacy-http:jar" {
+		t.Fatalf("legacy-http should be added, %#v", impact.Added)
+	}
+	// jackson-core is still brought in by jackson-databind
+	if len(impact.Removed) != 1 || impact.Removed[0].Artifact() != "org.postgresql:postgresql:jar" {
+		t.Fatalf("postgresql should be removed, %#v", impact.Removed)
+	}
+	if len(impact.NewConflicts) != 0 {
+		t.Fatalf("should have no new conflict, %#v", impact.NewConflicts)
+	}
+	if len(impact.ResolvedConflicts) != 1 || impact.ResolvedConflicts[0].ArtifactId != "commons-codec" {
+		t.Fatalf("commons-codec conflict should be resolved, %#v", impact.ResolvedConflicts)
+	}
+
+	// node ids are reused for the same coordinates
+	for _, n := range impact.Before.Nodes() {
+		c := impact.Before.coords[n.Id]
+		if ac, ok := impact.After.Coordinate(n.Id); ok && ac.Key() != c.Key() {
+			t.Fatalf("node %d should be %v, %v", n.Id, c.Key(), ac.Key())
+		}
+	}
+	added := impact.Added[0].NodeIds[0]
+	if _, ok := impact.Before.Node(added); ok {
+		t.Fatalf("added node should have a new id, %v", added)
+	}
+
+	impact.After.HighlightChanges(&impact.GraphDiff)
+	if n, _ := impact.After.Node(added); n.FillColor != addedNodeFillColor {
+		t.Fatalf("added node should be highlighted, %#v", n)
+	}
+
+	b, err := FormatUpgradeImpactJSON(impact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed map[string]any
+	if err := json.Unmarshal(b, &parsed); err != nil || parsed["changed"] == nil || parsed["upgrade"] == nil {
+		t.Fatalf("unexpected json, %v, %s", err, b)
+	}
+
+	impact, err = SimulateUpgrade("shop", "../../testdata/pom/shop", Upgrade{GroupId: "org.foo", ArtifactId: "bar", Version: "1.0"}, PomParam{Repo: "../../testdata/m2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !impact.Empty() {
+		t.Fatalf("unused artifact should not change anything, %#v", impact.GraphDiff)
+	}
+
+	for _, s := range []string{"", "a:b", "a:b:", "a:b:c:d"} {
+		if _, err := ParseUpgrade(s); err == nil {
+			t.Fatalf("'%v' should be invalid", s)
+		}
+	}
+}
+
+func TestSimulateUpgradeFrom(t *testing.T) {
+	// output of mvn dependency:tree -Dverbose of the shop project
+	tree := `com.example.shop:shop-parent:pom:1.0.0
+\- junit:junit:jar:4.13.2:test
+   \- org.hamcrest:hamcrest-core:jar:1.3:test
+com.example.shop:shop-common:jar:1.0.0
++- junit:junit:jar:4.13.2:test
+|  \- org.hamcrest:hamcrest-core:jar:1.3:test
++- org.slf4j:slf4j-api:jar:1.7.36:compile
+\- com.google.guava:guava:jar:32.1.2-jre:compile
+   +- com.google.guava:failureaccess:jar:1.0.1:compile
+   \- com.google.code.findbugs:jsr305:jar:3.0.2:compile
+com.example.shop:shop-service:jar:1.0.0
++- junit:junit:jar:4.13.2:test
+|  \- org.hamcrest:hamcrest-core:jar:1.3:test
++- com.example.shop:shop-common:jar:1.0.0:compile
+|  +- org.slf4j:slf4j-api:jar:1.7.36:compile
+|  \- com.google.guava:guava:jar:32.1.2-jre:compile
+|     +- com.google.guava:failureaccess:jar:1.0.1:compile
+|     \- com.google.code.findbugs:jsr305:jar:3.0.2:compile
++- com.example:legacy-client:jar:1.0:compile
+|  +- commons-codec:commons-codec:jar:1.11:compile
+|  +- com.example:legacy-util:jar:1.0:compile
+|  |  \- (commons-codec:commons-codec:jar:1.15:compile - omitted for conflict with 1.11)
+|  +- com.fasterxml.jackson.core:jackson-core:jar:2.15.2:compile
+|  \- org.postgresql:postgresql:jar:42.6.0:runtime
++- com.fasterxml.jackson.core:jackson-databind:jar:2.15.2:compile
+|  +- com.fasterxml.jackson.core:jackson-annotations:jar:2.15.2:compile
+|  \- (com.fasterxml.jackson.core:jackson-core:jar:2.15.2:compile - omitted for duplicate)
+\- org.apache.commons:commons-lang3:jar:3.12.0:compile
+`
+	before, err := ParseMvnGraph("shop", tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	impact, err := SimulateUpgradeFrom(before, "shop", "../../testdata/pom/shop", Upgrade{GroupId: "com.example", ArtifactId: "legacy-client", Version: "2.0"}, PomParam{Repo: "../../testdata/m2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("\n%v", FormatUpgradeImpact(impact))
+	if impact.Before != before {
+		t.Fatal("the existing graph should be used")
+	}
+	if len(impact.Changed) != 2 || len(impact.Added) != 1 || len(impact.Removed) != 1 || len(impact.NewConflicts) != 0 || len(impact.ResolvedConflicts) != 1 {
+		t.Fatalf("unexpected impact, %#v", impact.GraphDiff)
+	}
+
+	// node ids of the parsed graph are reused
+	for _, n := range impact.After.Nodes() {
+		c := impact.After.coords[n.Id]
+		if bc, ok := before.Coordinate(n.Id); ok && bc.Key() != c.Key() {
+			t.Fatalf("node %d should be %v, %v", n.Id, bc.Key(), c.Key())
+		}
+	}
+	n := before.FindArtifact("com.fasterxml.jackson.core", "jackson-databind")[0]
+	if an := impact.After.FindArtifact("com.fasterxml.jackson.core", "jackson-databind"); len(an) != 1 || an[0].Id != n.Id {
+		t.Fatalf("jackson-databind should have id %v, %v", n.Id, an)
+	}
+}
@@ -0,0 +1,209 @@
+package version
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Restriction of a version range, a nil bound is unbounded.
+type Restriction struct {
+	Lower          *Version
+	LowerInclusive bool
+	Upper          *Version
+	UpperInclusive bool
+}
+
+// Whether the version is within the restriction.
+func (r Restriction) Contains(v Version) bool {
+	if r.Lower != nil {
+		c := v.Compare(*r.Lower)
+		if c < 0 || (c == 0 && !r.LowerInclusive) {
+			return false
+		}
+	}
+	if r.Upper != nil {
+		c := v.Compare(*r.Upper)
+		if c > 0 || (c == 0 && !r.UpperInclusive) {
+			return false
+		}
+	}
+	return true
+}
+
+// Restriction in the same format it's declared, e.g., [1.0,2.0).
+func (r Restriction) String() string {
+	b := strings.Builder{}
+	if r.LowerInclusive {
+		b.WriteString("[")
+	} else {
+		b.WriteString("(")
+	}
+	if r.Lower != nil {
+		b.WriteString(r.Lower.String())
+	}
+	if r.Lower == nil || r.Upper == nil || !r.Lower.Equal(*r.Upper) || !r.LowerInclusive || !r.UpperInclusive {
+		b.WriteString(",")
+		if r.Upper != nil {
+			b.WriteString(r.Upper.String())
+		}
+	}
+	if r.UpperInclusive {
+		b.WriteString("]")
+	} else {
+		b.WriteString(")")
+	}
+	return b.String()
+}
+
+// Version range declared in pom, e.g.,
+//
+//	1.0              soft requirement of 1.0, any version is accepted
+//	[1.0]            exactly 1.0
+//	[1.0,2.0)        1.0 <= v < 2.0
+//	(,1.0],[1.2,)    v <= 1.0 or v >= 1.2
+//
+// See https://maven.apache.org/pom.html#dependency-version-requirement-specification.
+type Range struct {
+	Recommended  *Version      // soft requirement, only set if the range is a plain version
+	Restrictions []Restriction // restrictions in ascending order, empty if the range is a plain version
+}
+
+// Parse version range.
+func ParseRange(spec string) (Range, error) {
+	r := Range{}
+	s := strings.TrimSpace(spec)
+	if s == "" {
+		return r, fmt.Errorf("invalid version range '%v', version is empty", spec)
+	}
+	if !strings.HasPrefix(s, "[") && !strings.HasPrefix(s, "(") {
+		v := Parse(s)
+		r.Recommended = &v
+		return r, nil
+	}
+
+	for s != "" {
+		i := strings.IndexAny(s, ")]")
+		if i < 0 || (s[0] != '[' && s[0] != '(') {
+			return r, fmt.Errorf("invalid version range '%v', unbounded range", spec)
+		}
+		res, err := parseRestriction(s[:i+1])
+		if err != nil {
+			return r, fmt.Errorf("invalid version range '%v', %w", spec, err)
+		}
+		if n := len(r.Restrictions); n > 0 {
+			prev := r.Restrictions[n-1]
+			if prev.Upper == nil || res.Lower == nil || prev.Upper.Compare(*res.Lower) > 0 ||
+				(prev.Upper.Equal(*res.Lower) && prev.UpperInclusive && res.LowerInclusive) {
+				return r, fmt.Errorf("invalid version range '%v', ranges overlap", spec)
+			}
+		}
+		r.Restrictions = append(r.Restrictions, res)
+
+		s = strings.TrimSpace(s[i+1:])
+		if after, ok := strings.CutPrefix(s, ","); ok {
+			s = strings.TrimSpace(after)
+			if s == "" {
+				return r, fmt.Errorf("invalid version range '%v', missing range after ','", spec)
+			}
+		} else if s != "" {
+			return r, fmt.Errorf("invalid version range '%v', missing ',' between ranges", spec)
+		}
+	}
+	return r, nil
+}
+
+// Parse single restriction, e.g., [1.0,2.0), [1.0].
+func parseRestriction(s string) (Restriction, error) {
+	r := Restriction{LowerInclusive: s[0] == '[', UpperInclusive: s[len(s)-1] == ']'}
+	body := strings.TrimSpace(s[1 : len(s)-1])
+	lower, upper, found := strings.Cut(body, ",")
+	if !found {
+		if !r.LowerInclusive || !r.UpperInclusive {
+			return r, fmt.Errorf("single version must be surrounded by [], '%v'", s)
+		}
+		if body == "" {
+			return r, fmt.Errorf("version is empty, '%v'", s)
+		}
+		v := Parse(body)
+		r.Lower, r.Upper = &v, &v
+		return r, nil
+	}
+	if strings.Contains(upper, ",") {
+		return r, fmt.Errorf("too many versions, '%v'", s)
+	}
+	if lower = strings.TrimSpace(lower); lower != "" {
+		v := Parse(lower)
+		r.Lower = &v
+	}
+	if upper = strings.TrimSpace(upper); upper != "" {
+		v := Parse(upper)
+		r.Upper = &v
+	}
+	if r.Lower != nil && r.Upper != nil {
+		c := r.Upper.Compare(*r.Lower)
+		if c < 0 || (c == 0 && (!r.LowerInclusive || !r.UpperInclusive)) {
+			return r, fmt.Errorf("upper bound must be greater than lower bound, '%v'", s)
+		}
+	}
+	return r, nil
+}
+
+// Whether the range is a plain version, i.e., a soft requirement.
+func (r Range) IsVersion() bool {
+	return len(r.Restrictions) < 1
+}
+
+// Whether the version is within the range, any version is within a soft requirement.
+func (r Range) Contains(v Version) bool {
+	if r.IsVersion() {
+		return true
+	}
+	for _, res := range r.Restrictions {
+		if res.Contains(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// Highest one of the versions that are within the range, false if none of them matches.
+//
+// For a plain version, the recommended version is returned if it's present.
+func (r Range) Select(versions []string) (string, bool) {
+	if r.IsVersion() {
+		for _, s := range versions {
+			if Parse(s).Equal(*r.Recommended) {
+				return s, true
+			}
+		}
+		return "", false
+	}
+	matched := []string{}
+	for _, s := range versions {
+		if r.Contains(Parse(s)) {
+			matched = append(matched, s)
+		}
+	}
+	if len(matched) < 1 {
+		return "", false
+	}
+	return Max(matched...), true
+}
+
+// Range in the same format it's declared.
+func (r Range) String() string {
+	if r.IsVersion() {
+		return r.Recommended.String()
+	}
+	s := make([]string, 0, len(r.Restrictions))
+	for _, res := range r.Restrictions {
+		s = append(s, res.String())
+	}
+	return strings.Join(s, ",")
+}
+
+// Whether the version string is a range, i.e., it starts with '[' or '('.
+func IsRange(s string) bool {
+	s = strings.TrimSpace(s)
+	return strings.HasPrefix(s, "[") || strings.HasPrefix(s, "(")
+}
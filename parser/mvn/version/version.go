@@ -0,0 +1,333 @@
+package version
+
+import (
+	"strings"
+)
+
+// Well-known qualifiers in ascending order, unknown qualifiers are ordered after them lexically.
+var qualifiers = []string{"alpha", "beta", "milestone", "rc", "snapshot", "", "sp"}
+
+// Aliases of qualifiers.
+var aliases = map[string]string{
+	"ga":      "",
+	"final":   "",
+	"release": "",
+	"cr":      "rc",
+}
+
+// Index of the release qualifier (i.e., "") in qualifiers, e.g., 1.0 == 1.0-ga == 1.0-final.
+var releaseQualifier = comparableQualifier("")
+
+// Maven version, ordered in the same way as Maven's ComparableVersion.
+//
+// The version is split into numeric and qualifier segments on '.', '-' and transitions between digits and letters.
+// Numeric segments are compared numerically, qualifiers are ordered as
+//
+//	alpha < beta < milestone < rc = cr < snapshot < "" = ga = final = release < sp < (unknown qualifiers)
+//
+// and segments after '-' start a sub list, so 1.0-alpha < 1.0 < 1.0-sp < 1.0-1 < 1.0.1. Trailing zeros and release
+// qualifiers are ignored, i.e., 1 == 1.0 == 1.0.0 == 1-ga.
+//
+// See https://maven.apache.org/pom.html#version-order-specification.
+type Version struct {
+	raw   string
+	items listItem
+}
+
+// Parse version, any string is a valid version.
+func Parse(s string) Version {
+	return Version{raw: s, items: parseItems(strings.ToLower(strings.TrimSpace(s)))}
+}
+
+// Compare versions, returns -1 if a < b, 0 if a == b, and 1 if a > b.
+func Compare(a string, b string) int {
+	return Parse(a).Compare(Parse(b))
+}
+
+// Highest one of the versions, empty if there are no versions.
+func Max(versions ...string) string {
+	max := ""
+	var mv Version
+	for i, s := range versions {
+		v := Parse(s)
+		if i == 0 || v.Compare(mv) > 0 {
+			max, mv = s, v
+		}
+	}
+	return max
+}
+
+// Compare with the other version, returns -1 if v < o, 0 if v == o, and 1 if v > o.
+func (v Version) Compare(o Version) int {
+	return sign(v.items.compare(o.items))
+}
+
+// Whether the versions are equal in order, e.g., 1.0 equals 1.0.0.
+func (v Version) Equal(o Version) bool {
+	return v.Compare(o) == 0
+}
+
+// Whether the version is a snapshot, i.e., it ends with -SNAPSHOT.
+func (v Version) Snapshot() bool {
+	return strings.HasSuffix(strings.ToUpper(v.raw), "-SNAPSHOT")
+}
+
+// Raw version string.
+func (v Version) String() string {
+	return v.raw
+}
+
+// Canonical form of the version, versions that are equal have the same canonical form.
+func (v Version) Canonical() string {
+	return v.items.String()
+}
+
+// Segment of a version, item is nil if the segment is missing.
+type item interface {
+	compare(o item) int
+	isNull() bool
+	String() string
+}
+
+// Numeric segment, leading zeros are removed so that segments of any size can be compared.
+type intItem string
+
+func newIntItem(s string) intItem {
+	s = strings.TrimLeft(s, "0")
+	return intItem(s)
+}
+
+func (i intItem) compare(o item) int {
+	switch o := o.(type) {
+	case nil:
+		if i.isNull() {
+			return 0
+		}
+		return 1
+	case intItem:
+		if len(i) != len(o) {
+			return len(i) - len(o)
+		}
+		return strings.Compare(string(i), string(o))
+	case stringItem:
+		return 1 // 1.1 > 1-sp
+	default:
+		return 1 // 1.1 > 1-1
+	}
+}
+
+func (i intItem) isNull() bool {
+	return i == ""
+}
+
+func (i intItem) String() string {
+	if i == "" {
+		return "0"
+	}
+	return string(i)
+}
+
+// Qualifier segment.
+type stringItem string
+
+func newStringItem(s string, followedByDigit bool) stringItem {
+	if followedByDigit && len(s) == 1 {
+		// a1 = alpha-1, b1 = beta-1, m1 = milestone-1
+		switch s {
+		case "a":
+			s = "alpha"
+		case "b":
+			s = "beta"
+		case "m":
+			s = "milestone"
+		}
+	}
+	if a, ok := aliases[s]; ok {
+		s = a
+	}
+	return stringItem(s)
+}
+
+// Qualifier converted to a string that can be compared lexically.
+func comparableQualifier(q string) string {
+	for i, s := range qualifiers {
+		if s == q {
+			return string(rune('0' + i))
+		}
+	}
+	return string(rune('0'+len(qualifiers))) + "-" + q
+}
+
+func (s stringItem) compare(o item) int {
+	switch o := o.(type) {
+	case nil:
+		return strings.Compare(comparableQualifier(string(s)), releaseQualifier) // 1-rc < 1, 1-sp > 1
+	case intItem:
+		return -1
+	case stringItem:
+		return strings.Compare(comparableQualifier(string(s)), comparableQualifier(string(o)))
+	default:
+		return -1 // 1.any < 1-1
+	}
+}
+
+func (s stringItem) isNull() bool {
+	return comparableQualifier(string(s)) == releaseQualifier
+}
+
+func (s stringItem) String() string {
+	return string(s)
+}
+
+// List of segments, segments after '-' are nested in a sub list.
+type listItem []item
+
+func (l listItem) compare(o item) int {
+	switch o := o.(type) {
+	case nil:
+		if len(l) == 0 {
+			return 0
+		}
+		return l[0].compare(nil)
+	case intItem:
+		return -1 // 1-1 < 1.0.x
+	case stringItem:
+		return 1 // 1-1 > 1-sp
+	case listItem:
+		for i := 0; i < len(l) || i < len(o); i++ {
+			var li, ri item
+			if i < len(l) {
+				li = l[i]
+			}
+			if i < len(o) {
+				ri = o[i]
+			}
+			var c int
+			if li == nil {
+				if ri != nil {
+					c = -ri.compare(nil)
+				}
+			} else {
+				c = li.compare(ri)
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	}
+	return 0
+}
+
+func (l listItem) isNull() bool {
+	return len(l) == 0
+}
+
+func (l listItem) String() string {
+	b := strings.Builder{}
+	for i, it := range l {
+		if i > 0 {
+			if _, ok := it.(listItem); ok {
+				b.WriteString("-")
+			} else {
+				b.WriteString(".")
+			}
+		}
+		b.WriteString(it.String())
+	}
+	return b.String()
+}
+
+// Remove trailing null items (e.g., 0, ga) until a sub list is met.
+func (l listItem) normalize() listItem {
+	for i := len(l) - 1; i >= 0; i-- {
+		if l[i].isNull() {
+			l = append(l[:i], l[i+1:]...)
+		} else if _, ok := l[i].(listItem); !ok {
+			break
+		}
+	}
+	return l
+}
+
+// Parse lower-cased version into items, see ComparableVersion.parseVersion of maven-artifact.
+func parseItems(s string) listItem {
+	root := &node{}
+	list := root
+	push := func() {
+		sub := &node{}
+		list.items = append(list.items, sub)
+		list = sub
+	}
+	parse := func(digit bool, v string) any {
+		if digit {
+			return newIntItem(v)
+		}
+		return newStringItem(v, false)
+	}
+
+	digit := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '.' || c == '-':
+			if i == start {
+				list.items = append(list.items, newIntItem("0"))
+			} else {
+				list.items = append(list.items, parse(digit, s[start:i]))
+			}
+			start = i + 1
+			if c == '-' {
+				push()
+			}
+		case c >= '0' && c <= '9':
+			if !digit && i > start {
+				list.items = append(list.items, newStringItem(s[start:i], true))
+				start = i
+				push()
+			}
+			digit = true
+		default:
+			if digit && i > start {
+				list.items = append(list.items, parse(true, s[start:i]))
+				start = i
+				push()
+			}
+			digit = false
+		}
+	}
+	if len(s) > start {
+		list.items = append(list.items, parse(digit, s[start:]))
+	}
+	return root.build()
+}
+
+// Mutable list used while parsing, items are either item or *node.
+type node struct {
+	items []any
+}
+
+// Convert to listItem, sub lists are normalized before their parents as in ComparableVersion.
+func (n *node) build() listItem {
+	l := make(listItem, 0, len(n.items))
+	for _, it := range n.items {
+		switch it := it.(type) {
+		case *node:
+			l = append(l, it.build())
+		case item:
+			l = append(l, it)
+		}
+	}
+	return l.normalize()
+}
+
+func sign(c int) int {
+	if c < 0 {
+		return -1
+	}
+	if c > 0 {
+		return 1
+	}
+	return 0
+}
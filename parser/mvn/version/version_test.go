@@ -0,0 +1,127 @@
+package version
+
+import (
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	// each version is lower than the next one
+	ordered := []string{
+		"1-alpha-snapshot",
+		"1-alpha",
+		"1-alpha-1",
+		"1-alpha2",
+		"1-alpha-10",
+		"1-beta",
+		"1-b2",
+		"1-milestone",
+		"1-m2",
+		"1-rc",
+		"1-cr2",
+		"1-snapshot",
+		"1",
+		"1-sp",
+		"1-abc",
+		"1-xyz",
+		"1-1",
+		"1-2",
+		"1.1-SNAPSHOT",
+		"1.1",
+		"1.2",
+		"1.10",
+		"2.0.0-RC1",
+		"2.0.0",
+		"2.15.2",
+		"10.0",
+		"99999999999999999999.0",
+	}
+	for i := 0; i < len(ordered); i++ {
+		for j := 0; j < len(ordered); j++ {
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			if c := Compare(ordered[i], ordered[j]); c != want {
+				t.Fatalf("Compare(%v, %v) = %d, want %d", ordered[i], ordered[j], c, want)
+			}
+		}
+	}
+
+	equal := [][]string{
+		{"1", "1.0", "1.0.0", "1-ga", "1-final", "1.0-release", "1-0"},
+		{"1-rc1", "1-cr1", "1-rc-1"},
+		{"1-a1", "1-alpha-1", "1-ALPHA1"},
+		{"1.01", "1.1"},
+	}
+	for _, eq := range equal {
+		for _, v := range eq {
+			if c := Compare(eq[0], v); c != 0 {
+				t.Fatalf("%v should equal %v, %d", eq[0], v, c)
+			}
+			if Parse(eq[0]).Canonical() != Parse(v).Canonical() {
+				t.Fatalf("canonical of %v and %v should be the same, %v, %v", eq[0], v, Parse(eq[0]).Canonical(), Parse(v).Canonical())
+			}
+		}
+	}
+
+	if m := Max("1.9", "1.10", "1.10-SNAPSHOT", "1.2"); m != "1.10" {
+		t.Fatalf("max should be 1.10, %v", m)
+	}
+	if !Parse("1.0-SNAPSHOT").Snapshot() || Parse("1.0").Snapshot() {
+		t.Fatal("unexpected snapshot")
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		spec     string
+		contains []string
+		excludes []string
+	}{
+		{"1.0", []string{"0.1", "1.0", "3"}, nil},
+		{"[1.0]", []string{"1.0", "1"}, []string{"1.0.1", "0.9"}},
+		{"[1.0,2.0)", []string{"1.0", "1.5", "1.99", "2.0-SNAPSHOT"}, []string{"0.9", "2.0", "2.0.1"}},
+		{"(1.0,2.0]", []string{"1.0.1", "2.0"}, []string{"1.0", "2.0.1"}},
+		{"[1.5,)", []string{"1.5", "10"}, []string{"1.4"}},
+		{"(,1.0],[1.2,)", []string{"0.1", "1.0", "1.2", "5"}, []string{"1.1"}},
+	}
+	for _, tt := range tests {
+		r, err := ParseRange(tt.spec)
+		if err != nil {
+			t.Fatalf("%v, %v", tt.spec, err)
+		}
+		if s := r.String(); s != tt.spec {
+			t.Fatalf("String() = %v, want %v", s, tt.spec)
+		}
+		for _, v := range tt.contains {
+			if !r.Contains(Parse(v)) {
+				t.Fatalf("%v should contain %v", tt.spec, v)
+			}
+		}
+		for _, v := range tt.excludes {
+			if r.Contains(Parse(v)) {
+				t.Fatalf("%v should not contain %v", tt.spec, v)
+			}
+		}
+	}
+
+	for _, spec := range []string{"", "[1.0", "(1.0)", "[2.0,1.0]", "[1.0,2.0,3.0]", "[1.0,2.0],", "[1.0,2.0][3.0,)", "[1.0,2.0],[1.5,3.0]", "(1.0,1.0]"} {
+		if _, err := ParseRange(spec); err == nil {
+			t.Fatalf("%v should be invalid", spec)
+		}
+	}
+
+	r, _ := ParseRange("[1.0,2.0)")
+	if v, ok := r.Select([]string{"0.9", "1.1", "1.9", "2.0", "1.10"}); !ok || v != "1.10" {
+		t.Fatalf("should select 1.10, %v", v)
+	}
+	if _, ok := r.Select([]string{"2.0"}); ok {
+		t.Fatal("should select nothing")
+	}
+	r, _ = ParseRange("1.0")
+	if v, ok := r.Select([]string{"1.0.0", "2.0"}); !ok || v != "1.0.0" {
+		t.Fatalf("should select 1.0.0, %v", v)
+	}
+}
@@ -0,0 +1,227 @@
+package mvn
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/curtisnewbie/grapher/graph"
+)
+
+const (
+	AttrSize = "size" // size of the artifact file in bytes, only available after AnnotateSizes
+
+	weightNodeFillColor = "#fff5f5" // fill color of the lightest nodes
+	heavyNodeFillColor  = "#fa5252" // fill color of the heaviest nodes
+)
+
+// Weight of a direct dependency, i.e., sizes of the artifact files that the dependency brings in.
+type Weight struct {
+	Module             Coordinate `json:"module"`
+	Dependency         Coordinate `json:"dependency"`
+	Size               int64      `json:"size"`               // size of the dependency itself
+	Total              int64      `json:"total"`              // size of the dependency and all its transitive dependencies
+	Exclusive          int64      `json:"exclusive"`          // size of the dependency and the transitive dependencies that are only reachable through it
+	Artifacts          int        `json:"artifacts"`          // number of artifacts included in Total
+	ExclusiveArtifacts int        `json:"exclusiveArtifacts"` // number of artifacts included in Exclusive
+	NodeIds            []int      `json:"-"`                  // nodes included in Exclusive
+
+	dependencyId int
+}
+
+// Local repository file of the artifact, false if the artifact doesn't have a file other than the pom.
+func artifactFile(repo string, c Coordinate) (string, bool) {
+	ext := c.Packaging
+	switch ext {
+	case "pom":
+		return "", false
+	case "", "bundle", "maven-plugin", "ejb", "test-jar":
+		ext = "jar"
+	}
+	return repoPath(repo, c, ext), true
+}
+
+// Annotate nodes with sizes of the artifact files found in the local repository, the size in bytes is stored in node
+// attribute AttrSize and shown in the tooltip. Artifacts whose files are not found are returned, modules and
+// artifacts with pom packaging are ignored.
+func (g *MvnGraph) AnnotateSizes(repo string) []Coordinate {
+	missing := []Coordinate{}
+	if g.sizes == nil {
+		g.sizes = map[int]int64{}
+	}
+	for _, n := range g.Nodes() {
+		c := g.coords[n.Id]
+		if g.IsModule(n.Id) {
+			continue
+		}
+		path, ok := artifactFile(repo, c)
+		if !ok {
+			continue
+		}
+		fi, err := os.Stat(path)
+		if err != nil || fi.IsDir() {
+			missing = append(missing, c)
+			continue
+		}
+		size := fi.Size()
+		g.sizes[n.Id] = size
+		g.UpdateNode(n.Id, func(n *graph.Node) {
+			if n.Attrs == nil {
+				n.Attrs = map[string]string{}
+			}
+			n.Attrs[AttrSize] = fmt.Sprintf("%d", size)
+			n.Tooltip = FormatSize(size)
+		})
+	}
+	return missing
+}
+
+// Size of the artifact file of the node, false if it's not annotated by AnnotateSizes.
+func (g *MvnGraph) Size(id int) (int64, bool) {
+	s, ok := g.sizes[id]
+	return s, ok
+}
+
+// Whether the dependency is packaged with the module, i.e., it's on the runtime classpath.
+func packaged(d Dependency) bool {
+	return d.Scope == "" || d.Scope == ScopeCompile || d.Scope == ScopeRuntime
+}
+
+// Weights of the direct dependencies of each module, sorted by the exclusive weight in descending order, sizes must be
+// annotated by AnnotateSizes first.
+//
+// Only dependencies on the runtime classpath (compile and runtime scope) are included. Edges omitted for duplicate are
+// followed as well since they are real dependencies, so the output of mvn dependency:tree -Dverbose gives more
+// accurate exclusive weights, e.g., a transitive dependency shared by two direct dependencies is not exclusive to any
+// of them. Edges omitted for conflict are not followed because the version is not on the classpath.
+//
+// The exclusive part is the set of nodes dominated by the direct dependency in the module's graph (see
+// graph.DGraph.Dominators), i.e., what disappears if the direct dependency is removed.
+func (g *MvnGraph) Weights() ([]Weight, error) {
+	follow := func(d Dependency) bool {
+		return d.Kind != EdgeOmittedConflict && packaged(d)
+	}
+	weights := []Weight{}
+	for _, m := range g.modules {
+		dt, err := g.Dominators(m, func(ed graph.DEdge) bool {
+			k := [2]int{ed.FromId, ed.ToId}
+			return containsInt(g.edgeModules[k], m) && follow(g.deps[k])
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find dominators of module %v, %w", g.coords[m].Key(), err)
+		}
+		for _, ed := range g.OutEdges(m) {
+			d := ed.ToId
+			dep := g.deps[[2]int{m, d}]
+			if dep.Omitted() || !follow(dep) {
+				continue
+			}
+			w := Weight{Module: g.coords[m], Dependency: g.coords[d], Size: g.sizes[d], dependencyId: d}
+			for id := range g.moduleReachable(m, d, follow) {
+				w.Total += g.sizes[id]
+				w.Artifacts++
+			}
+			w.NodeIds = dt.Exclusive(d)
+			w.ExclusiveArtifacts = len(w.NodeIds)
+			for _, id := range w.NodeIds {
+				w.Exclusive += g.sizes[id]
+			}
+			weights = append(weights, w)
+		}
+	}
+	sort.SliceStable(weights, func(i, j int) bool {
+		if weights[i].Exclusive != weights[j].Exclusive {
+			return weights[i].Exclusive > weights[j].Exclusive
+		}
+		return weights[i].Total > weights[j].Total
+	})
+	return weights, nil
+}
+
+// Fill nodes with colors proportional to the weights, direct dependencies are weighted by the exclusive weights, and
+// other nodes are weighted by their own sizes. The weights must be computed from the same graph, they are appended to
+// the labels of the direct dependencies.
+func (g *MvnGraph) HighlightWeights(weights []Weight) {
+	nodeWeights := map[int]int64{}
+	for id, s := range g.sizes {
+		nodeWeights[id] = s
+	}
+	labels := map[int][]string{}
+	for _, w := range weights {
+		id := w.dependencyId
+		if w.Exclusive > nodeWeights[id] {
+			nodeWeights[id] = w.Exclusive
+		}
+		labels[id] = append(labels[id], fmt.Sprintf("exclusive: %v, total: %v", FormatSize(w.Exclusive), FormatSize(w.Total)))
+	}
+	var max int64
+	for _, w := range nodeWeights {
+		if w > max {
+			max = w
+		}
+	}
+	if max < 1 {
+		return
+	}
+	for id, w := range nodeWeights {
+		fill := blend(weightNodeFillColor, heavyNodeFillColor, float64(w)/float64(max))
+		extra := labels[id]
+		g.UpdateNode(id, func(n *graph.Node) {
+			n.FillColor = fill
+			if len(extra) > 0 {
+				n.Label += "\n" + strings.Join(extra, "\n")
+			}
+		})
+	}
+}
+
+// Blend two #rrggbb colors, ratio 0 is the first color and ratio 1 is the second color.
+func blend(from string, to string, ratio float64) string {
+	var fr, fg, fb, tr, tg, tb int
+	_, _ = fmt.Sscanf(from, "#%02x%02x%02x", &fr, &fg, &fb)
+	_, _ = fmt.Sscanf(to, "#%02x%02x%02x", &tr, &tg, &tb)
+	mix := func(a int, b int) int {
+		return a + int(float64(b-a)*ratio)
+	}
+	return fmt.Sprintf("#%02x%02x%02x", mix(fr, tr), mix(fg, tg), mix(fb, tb))
+}
+
+// Format size in bytes as human-readable text, e.g., 1.5 MB.
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// Format weights as a human-readable ranking.
+func FormatWeights(weights []Weight) string {
+	b := strings.Builder{}
+	modules := map[string]struct{}{}
+	for _, w := range weights {
+		modules[w.Module.Key()] = struct{}{}
+	}
+	b.WriteString(fmt.Sprintf("%-4v %10v %10v %10v  %v\n", "#", "Exclusive", "Total", "Artifacts", "Dependency"))
+	for i, w := range weights {
+		dep := w.Dependency.Key()
+		if len(modules) > 1 {
+			dep += " (" + w.Module.ArtifactId + ")"
+		}
+		b.WriteString(fmt.Sprintf("%-4d %10v %10v %10v  %v\n", i+1, FormatSize(w.Exclusive), FormatSize(w.Total),
+			fmt.Sprintf("%d/%d", w.ExclusiveArtifacts, w.Artifacts), dep))
+	}
+	return b.String()
+}
+
+// Format weights as JSON.
+func FormatWeightsJSON(weights []Weight) ([]byte, error) {
+	return json.MarshalIndent(weights, "", "  ")
+}
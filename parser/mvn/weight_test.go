@@ -0,0 +1,96 @@
+package mvn
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWeights(t *testing.T) {
+	ctn, err := os.ReadFile("../../testdata/verbose_mvn.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := ParseMvnGraph("dependency tree", string(ctn))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// each jar is 1KB, except the ones that are not written
+	repo := t.TempDir()
+	sizes := map[string]int64{}
+	for _, n := range g.Nodes() {
+		c := g.coords[n.Id]
+		if g.IsModule(n.Id) || c.ArtifactId == "joda-time" {
+			continue
+		}
+		path, _ := artifactFile(repo, c)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, 1024), 0644); err != nil {
+			t.Fatal(err)
+		}
+		sizes[c.ArtifactId] = 1024
+	}
+
+	missing := g.AnnotateSizes(repo)
+	if len(missing) != 1 || missing[0].ArtifactId != "joda-time" {
+		t.Fatalf("joda-time should be missing, %v", missing)
+	}
+	n := g.FindArtifact("org.apache.httpcomponents", "httpclient")[0]
+	if s, ok := g.Size(n.Id); !ok || s != 1024 {
+		t.Fatalf("size of httpclient should be 1024, %v", s)
+	}
+	if n, _ := g.Node(n.Id); n.Attrs[AttrSize] != "1024" {
+		t.Fatalf("size should be annotated, %v", n.Attrs)
+	}
+
+	weights, err := g.Weights()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("\n%v", FormatWeights(weights))
+	byArtifact := map[string]Weight{}
+	for _, w := range weights {
+		byArtifact[w.Dependency.ArtifactId] = w
+	}
+	if _, ok := byArtifact["junit"]; ok {
+		t.Fatal("test dependencies should not be weighted")
+	}
+	// commons-logging is also brought in by aws-java-sdk-core, so it's not exclusive to httpclient
+	cases := map[string][2]int64{
+		"httpclient":           {4 * 1024, 3 * 1024},
+		"aws-java-sdk-core":    {5 * 1024, 1024},
+		"jackson-databind":     {3 * 1024, 3 * 1024},
+		"spring-core":          {2 * 1024, 2 * 1024},
+		"mysql-connector-java": {2 * 1024, 1024},
+		"protobuf-java":        {1024, 1024},
+	}
+	if len(weights) != len(cases) {
+		t.Fatalf("unexpected weights, %v", byArtifact)
+	}
+	for a, want := range cases {
+		w := byArtifact[a]
+		if w.Total != want[0] || w.Exclusive != want[1] {
+			t.Fatalf("%v should have total %v and exclusive %v, %#v", a, want[0], want[1], w)
+		}
+	}
+	// httpclient and jackson-databind have the same exclusive weight, the total weight is compared
+	if weights[0].Dependency.ArtifactId != "httpclient" {
+		t.Fatalf("the heaviest should be ranked first, %v", weights[0].Dependency)
+	}
+	if w := byArtifact["aws-java-sdk-core"]; w.ExclusiveArtifacts != 2 || w.Artifacts != 6 {
+		t.Fatalf("aws-java-sdk-core should exclusively bring in itself and joda-time, %#v", w)
+	}
+
+	g.HighlightWeights(weights)
+	hc, _ := g.Node(byArtifact["httpclient"].dependencyId)
+	if hc.FillColor != heavyNodeFillColor {
+		t.Fatalf("httpclient should be the heaviest, %v", hc.FillColor)
+	}
+
+	if s := FormatSize(1536 * 1024); s != "1.5 MB" {
+		t.Fatalf("unexpected size, %v", s)
+	}
+}